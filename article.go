@@ -1,24 +1,74 @@
 package strichliste
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/jktr/go-strichliste/barcode"
 	"github.com/jktr/go-strichliste/schema"
 	"net/http"
+	"sort"
 )
 
-// An ArticleClient carries the necessary context to interact
-// with the /article endpoint
-type ArticleClient struct {
-	client *Client
+// Wrapped by a BarcodeError if several active articles share a barcode.
+var ErrAmbiguousBarcode = errors.New("barcode matches several active articles")
+
+type (
+	// An ArticleClient carries the necessary context to interact
+	// with the /article endpoint
+	ArticleClient struct {
+		client *Client
+	}
+
+	// A BarcodeError describes why a barcode couldn't be resolved to
+	// a single active article, or was rejected with strict barcodes.
+	BarcodeError struct {
+		Barcode string
+
+		// The articles matching the barcode exactly; the active ones
+		// if ambiguous, the inactive ones if none are active. With
+		// strict barcodes, the active articles already using it.
+		Articles []schema.Article
+
+		// One of schema.ErrorArticleNotFound,
+		// schema.ErrorArticleInactive, or ErrAmbiguousBarcode.
+		// With strict barcodes, schema.ErrorArticleBarcodeAlreadyExists
+		// or an error of the barcode package.
+		Err error
+	}
+)
+
+func (e *BarcodeError) Error() string {
+	switch e.Err {
+	case ErrAmbiguousBarcode:
+		return fmt.Sprintf("barcode %q matches %d active articles", e.Barcode, len(e.Articles))
+	case schema.ErrorArticleInactive:
+		return fmt.Sprintf("barcode %q matches only inactive articles", e.Barcode)
+	case schema.ErrorArticleNotFound:
+		return fmt.Sprintf("barcode %q matches no article", e.Barcode)
+	case schema.ErrorArticleBarcodeAlreadyExists:
+		return fmt.Sprintf("barcode %q is already used by article %d", e.Barcode, e.Articles[0].ID)
+	}
+	return fmt.Sprintf("invalid barcode: %v", e.Err)
+}
+
+func (e *BarcodeError) Unwrap() error {
+	return e.Err
 }
 
 // POST /article
 //   - ErrorParameterMissing
 //   - ErrorParameterInvalid
+//   - ErrorArticleBarcodeAlreadyExists
 //
-// Creates a new article and returns it.
-func (s *ArticleClient) Create(article *schema.ArticleCreateRequest) (*schema.Article, *Response, error) {
-	req, err := s.client.NewRequest(http.MethodPost, schema.EndpointArticle, article)
+// Creates a new article and returns it. With strict barcodes, the
+// barcode is checked beforehand; see WithStrictBarcodes.
+func (s *ArticleClient) Create(ctx context.Context, article *schema.ArticleCreateRequest) (*schema.Article, *Response, error) {
+	if err := s.checkBarcode(ctx, article.Barcode, 0); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest(ctx, http.MethodPost, schema.EndpointArticle, article)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -28,16 +78,17 @@ func (s *ArticleClient) Create(article *schema.ArticleCreateRequest) (*schema.Ar
 	if err != nil {
 		return nil, resp, err
 	}
+	s.InvalidateCache()
 	return &body.Article, resp, nil
 }
 
 // GET /article/{articleId}
 //
 // Retrieves an article by ID.
-func (s *ArticleClient) Get(id int) (*schema.Article, *Response, error) {
+func (s *ArticleClient) Get(ctx context.Context, id int) (*schema.Article, *Response, error) {
 	path := fmt.Sprintf("%s/%d", schema.EndpointArticle, id)
 
-	req, err := s.client.NewRequest(http.MethodGet, path, nil)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -54,10 +105,10 @@ func (s *ArticleClient) Get(id int) (*schema.Article, *Response, error) {
 //
 // Retrieves a list of articles (both active and inactive).
 // Pagination is possible via ListOpts, which can be nil.
-func (s *ArticleClient) List(opt *ListOpts) ([]schema.Article, *Response, error) {
+func (s *ArticleClient) List(ctx context.Context, opt *ListOpts) ([]schema.Article, *Response, error) {
 	path := fmt.Sprintf("%s?%s", schema.EndpointArticle, opt.values().Encode())
 
-	req, err := s.client.NewRequest(http.MethodGet, path, nil)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -71,14 +122,30 @@ func (s *ArticleClient) List(opt *ListOpts) ([]schema.Article, *Response, error)
 	return body.Articles, resp, nil
 }
 
-func (s *ArticleClient) searchByX(x, query string, opt *ListOpts) ([]schema.Article, *Response, error) {
+// GET /article
+//
+// Returns an iterator over all articles (both active and inactive).
+// Options can be nil.
+func (s *ArticleClient) ListIter(opt *IterOpts) *ArticleIterator {
+	return &ArticleIterator{pager: newPager(opt), list: s.List}
+}
+
+// GET /article
+//
+// Retrieves all articles, walking all pages; see ListIter.
+// Returns the response of the last page.
+func (s *ArticleClient) ListAll(ctx context.Context, opt *IterOpts) ([]schema.Article, *Response, error) {
+	return allArticles(ctx, s.ListIter(opt))
+}
+
+func (s *ArticleClient) searchByX(ctx context.Context, x, query string, opt *ListOpts) ([]schema.Article, *Response, error) {
 
 	v := opt.values()
 	v.Add(x, query)
 
 	path := fmt.Sprintf("%s?%s", schema.EndpointArticleSearch, v.Encode())
 
-	req, err := s.client.NewRequest(http.MethodGet, path, nil)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -96,32 +163,131 @@ func (s *ArticleClient) searchByX(x, query string, opt *ListOpts) ([]schema.Arti
 //
 // Retrieves a list of articles whose names match, or include, the
 // passed name. Pagination is possible via ListOpts, which can be nil.
-func (s *ArticleClient) SearchByName(name string, opt *ListOpts) ([]schema.Article, *Response, error) {
-	return s.searchByX("query", name, opt)
+func (s *ArticleClient) SearchByName(ctx context.Context, name string, opt *ListOpts) ([]schema.Article, *Response, error) {
+	return s.searchByX(ctx, "query", name, opt)
 }
 
 // GET /article/search
 //
 // Retrieves a list of articles whose barcodes match, or include, the
 // passed barcode. Pagination is possible via ListOpts, which can be nil.
-func (s *ArticleClient) SearchByBarcode(barcode string, opt *ListOpts) ([]schema.Article, *Response, error) {
-	return s.searchByX("barcode", string(barcode), opt)
+func (s *ArticleClient) SearchByBarcode(ctx context.Context, barcode string, opt *ListOpts) ([]schema.Article, *Response, error) {
+	return s.searchByX(ctx, "barcode", string(barcode), opt)
+}
+
+func (s *ArticleClient) searchByXIter(x, query string, opt *IterOpts) *ArticleIterator {
+	list := func(ctx context.Context, opt *ListOpts) ([]schema.Article, *Response, error) {
+		return s.searchByX(ctx, x, query, opt)
+	}
+	return &ArticleIterator{pager: newPager(opt), list: list}
+}
+
+// GET /article/search
+//
+// Returns an iterator over all articles whose names match, or
+// include, the passed name. Options can be nil.
+func (s *ArticleClient) SearchByNameIter(name string, opt *IterOpts) *ArticleIterator {
+	return s.searchByXIter("query", name, opt)
+}
+
+// GET /article/search
+//
+// Retrieves all articles whose names match, or include, the passed
+// name, walking all pages; see SearchByNameIter.
+// Returns the response of the last page.
+func (s *ArticleClient) SearchByNameAll(ctx context.Context, name string, opt *IterOpts) ([]schema.Article, *Response, error) {
+	return allArticles(ctx, s.SearchByNameIter(name, opt))
+}
+
+// GET /article/search
+//
+// Returns an iterator over all articles whose barcodes match, or
+// include, the passed barcode. Options can be nil.
+func (s *ArticleClient) SearchByBarcodeIter(barcode string, opt *IterOpts) *ArticleIterator {
+	return s.searchByXIter("barcode", barcode, opt)
+}
+
+// GET /article/search
+//
+// Retrieves all articles whose barcodes match, or include, the passed
+// barcode, walking all pages; see SearchByBarcodeIter.
+// Returns the response of the last page.
+func (s *ArticleClient) SearchByBarcodeAll(ctx context.Context, barcode string, opt *IterOpts) ([]schema.Article, *Response, error) {
+	return allArticles(ctx, s.SearchByBarcodeIter(barcode, opt))
+}
+
+// GET /article/search
+//   - ErrorArticleNotFound
+//   - ErrorArticleInactive
+//   - ErrAmbiguousBarcode
+//
+// Retrieves the active article whose barcode matches the passed
+// barcode exactly. Unlike SearchByBarcode, this ignores partial
+// matches and inactive articles. Errors are a *BarcodeError that
+// wraps one of the above.
+//
+// Uses the article cache if enabled; see WithArticleCache.
+func (s *ArticleClient) GetByBarcode(ctx context.Context, barcode string) (*schema.Article, *Response, error) {
+	if s.client.articleCache.enabled {
+		cat, err := s.catalog(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		a, err := matchBarcode(barcode, cat.byBarcode[barcode])
+		return a, nil, err
+	}
+
+	articles, resp, err := s.SearchByBarcodeAll(ctx, barcode, nil)
+	if err != nil {
+		return nil, resp, err
+	}
+	a, err := matchBarcode(barcode, articles)
+	return a, resp, err
+}
+
+// Picks the single active article whose barcode matches exactly.
+func matchBarcode(barcode string, articles []schema.Article) (*schema.Article, error) {
+	var exact, active []schema.Article
+	for _, a := range articles {
+		if a.Barcode != nil && *a.Barcode == barcode {
+			exact = append(exact, a)
+			if a.IsActive {
+				active = append(active, a)
+			}
+		}
+	}
+
+	switch {
+	case len(active) == 1:
+		return &active[0], nil
+	case len(active) > 1:
+		return nil, &BarcodeError{Barcode: barcode, Articles: active, Err: ErrAmbiguousBarcode}
+	case len(exact) > 0:
+		return nil, &BarcodeError{Barcode: barcode, Articles: exact, Err: schema.ErrorArticleInactive}
+	}
+	return nil, &BarcodeError{Barcode: barcode, Err: schema.ErrorArticleNotFound}
 }
 
 // POST /article/{articleId}
 //   - ErrorArticleNotFound
 //   - ErrorParameterMissing
 //   - ErrorParameterInvalid
+//   - ErrorArticleBarcodeAlreadyExists
 //
 // Updates an article by ID. Note that this operation checks for
 // referential integrity and may not update the article, but instead
 // create a new one, referencing and deactivating the old version.
 // The returned article is always new version — either replaced or
-// updated.
-func (s *ArticleClient) Update(id int, article *schema.ArticleUpdateRequest) (*schema.Article, *Response, error) {
+// updated. With strict barcodes, the barcode is checked beforehand;
+// see WithStrictBarcodes.
+func (s *ArticleClient) Update(ctx context.Context, id int, article *schema.ArticleUpdateRequest) (*schema.Article, *Response, error) {
+	if err := s.checkBarcode(ctx, article.Barcode, id); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("%s/%d", schema.EndpointArticle, id)
 
-	req, err := s.client.NewRequest(http.MethodPost, path, article)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, article)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -131,6 +297,7 @@ func (s *ArticleClient) Update(id int, article *schema.ArticleUpdateRequest) (*s
 	if err != nil {
 		return nil, resp, err
 	}
+	s.InvalidateCache()
 	return &body.Article, resp, nil
 }
 
@@ -139,10 +306,10 @@ func (s *ArticleClient) Update(id int, article *schema.ArticleUpdateRequest) (*s
 //
 // Deactivates an article by ID; returns the deactivated article.
 // Note that actual deletion is not possible.
-func (s *ArticleClient) Deactivate(id int) (*schema.Article, *Response, error) {
+func (s *ArticleClient) Deactivate(ctx context.Context, id int) (*schema.Article, *Response, error) {
 	path := fmt.Sprintf("%s/%d", schema.EndpointArticle, id)
 
-	req, err := s.client.NewRequest(http.MethodDelete, path, nil)
+	req, err := s.client.NewRequest(ctx, http.MethodDelete, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -152,5 +319,71 @@ func (s *ArticleClient) Deactivate(id int) (*schema.Article, *Response, error) {
 	if err != nil {
 		return nil, resp, err
 	}
+	s.InvalidateCache()
 	return &body.Article, resp, nil
 }
+
+// Enable strict barcodes: ArticleClient.Create and Update then reject
+// barcodes with a wrong check digit or unsupported format, and
+// barcodes that denote the same product as an active article's, even
+// in another form like UPC-A versus EAN-13; see the barcode package.
+// Errors are a *BarcodeError, and are returned before creating or
+// updating the article. Uses the article cache if enabled, and searches
+// articles by each form of the barcode otherwise; see WithArticleCache.
+func WithStrictBarcodes() ClientOption {
+	return func(client *Client) {
+		client.strictBarcodes = true
+	}
+}
+
+// Checks a barcode that is about to be assigned to an article, which
+// is zero for new ones.
+func (s *ArticleClient) checkBarcode(ctx context.Context, code string, article int) error {
+	if !s.client.strictBarcodes || code == "" {
+		return nil
+	}
+	if err := barcode.Validate(code); err != nil {
+		return &BarcodeError{Barcode: code, Err: err}
+	}
+
+	candidates, err := s.barcodeCandidates(ctx, code)
+	if err != nil {
+		return err
+	}
+	var others []schema.Article
+	for id, a := range candidates {
+		if id != article {
+			others = append(others, a)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].ID < others[j].ID })
+	if dups := barcode.Duplicates(code, others); dups != nil {
+		return &BarcodeError{Barcode: code, Articles: dups, Err: schema.ErrorArticleBarcodeAlreadyExists}
+	}
+	return nil
+}
+
+// Collects the articles that may carry a barcode in any of its forms,
+// by ID. Uses the article cache if enabled, and searches for each form
+// otherwise.
+func (s *ArticleClient) barcodeCandidates(ctx context.Context, code string) (map[int]schema.Article, error) {
+	if s.client.articleCache.enabled {
+		cat, err := s.catalog(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return cat.byID, nil
+	}
+
+	candidates := make(map[int]schema.Article)
+	for _, form := range barcode.Forms(code) {
+		articles, _, err := s.SearchByBarcodeAll(ctx, form, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range articles {
+			candidates[a.ID] = a
+		}
+	}
+	return candidates, nil
+}
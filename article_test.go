@@ -0,0 +1,105 @@
+package strichliste_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/barcode"
+	"github.com/jktr/go-strichliste/schema"
+	"github.com/jktr/go-strichliste/strichlistetest"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Records the paths of the requests passing through.
+type recorder struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.paths = append(r.paths, req.Method+" "+req.URL.Path)
+	r.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (r *recorder) requested(path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStrictBarcodes(t *testing.T) {
+	ctx := context.Background()
+
+	for _, cached := range []bool{false, true} {
+		srv := strichlistetest.NewServer()
+		rec := &recorder{}
+		opts := []strichliste.ClientOption{strichliste.WithStrictBarcodes(), strichliste.WithTransport(rec)}
+		if cached {
+			opts = append(opts, strichliste.WithArticleCache(time.Minute))
+		}
+		client := srv.NewClient(opts...)
+
+		ean13 := srv.AddArticle("Soda", 100, "0036000291452")
+		upca := srv.AddArticle("Chips", 150, "042100005264")
+		old := srv.AddArticle("Old", 150, "4006381333931")
+		if _, _, err := client.Article.Deactivate(ctx, old.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name    string
+			barcode string
+			id      int   // article to update; zero creates one
+			err     error // expected cause
+			dup     int   // ID of the expected duplicate
+		}{
+			{"wrong check digit", "0036000291453", 0, barcode.ErrCheckDigit, 0},
+			{"unsupported length", "12345", 0, barcode.ErrLength, 0},
+			{"same code", "0036000291452", 0, schema.ErrorArticleBarcodeAlreadyExists, ean13.ID},
+			{"UPC-A of an EAN-13", "036000291452", 0, schema.ErrorArticleBarcodeAlreadyExists, ean13.ID},
+			{"UPC-E of a UPC-A", "04252614", 0, schema.ErrorArticleBarcodeAlreadyExists, upca.ID},
+			{"EAN-13 of a UPC-A", "0042100005264", ean13.ID, schema.ErrorArticleBarcodeAlreadyExists, upca.ID},
+			{"own code in another form", "04252614", upca.ID, nil, 0},
+			{"code of an inactive article", "4006381333931", 0, nil, 0},
+			{"new code", "96385074", 0, nil, 0},
+		}
+		for _, tt := range tests {
+			var err error
+			if tt.id == 0 {
+				_, _, err = client.Article.Create(ctx, &schema.ArticleCreateRequest{Name: tt.name, Value: 100, Barcode: tt.barcode})
+			} else {
+				var a *schema.Article
+				a, _, err = client.Article.Get(ctx, tt.id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, _, err = client.Article.Update(ctx, tt.id, &schema.ArticleUpdateRequest{Name: a.Name, Value: a.Value, Barcode: tt.barcode})
+			}
+
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("cached=%v, %s: err = %v, want %v", cached, tt.name, err, tt.err)
+				continue
+			}
+			var be *strichliste.BarcodeError
+			if tt.dup != 0 && (!errors.As(err, &be) || len(be.Articles) != 1 || be.Articles[0].ID != tt.dup) {
+				t.Errorf("cached=%v, %s: err = %v, want duplicate %d", cached, tt.name, err, tt.dup)
+			}
+		}
+
+		if !cached && (rec.requested("GET "+strichlistetest.PathPrefix+schema.EndpointArticle) ||
+			!rec.requested("GET "+strichlistetest.PathPrefix+schema.EndpointArticleSearch)) {
+			t.Errorf("checking barcodes without the article cache didn't search: %q", rec.paths)
+		}
+		srv.Close()
+	}
+}
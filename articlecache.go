@@ -0,0 +1,152 @@
+package strichliste
+
+import (
+	"context"
+	"github.com/jktr/go-strichliste/schema"
+	"sync"
+	"time"
+)
+
+type (
+	// Caches the article catalog; see WithArticleCache.
+	articleCache struct {
+		mu      sync.Mutex
+		enabled bool
+		ttl     time.Duration // non-positive means no expiry
+		catalog *articleCatalog
+		fetched time.Time
+	}
+
+	// An immutable snapshot of all articles.
+	articleCatalog struct {
+		byID       map[int]schema.Article
+		byBarcode  map[string][]schema.Article
+		successors map[int]int // precursor ID to successor ID
+	}
+)
+
+// Enable caching the article catalog, which saves Purchase and
+// GetByBarcode a request each. The catalog is retrieved again once it
+// is older than the TTL; a non-positive TTL means it is only retrieved
+// again on RefreshCache or after InvalidateCache.
+//
+// The cache is invalidated whenever this client creates, updates, or
+// deactivates an article, and whenever the server rejects a purchase
+// because the article is unknown or inactive.
+func WithArticleCache(ttl time.Duration) ClientOption {
+	return func(client *Client) {
+		client.articleCache.enabled = true
+		client.articleCache.ttl = ttl
+	}
+}
+
+func newArticleCatalog(articles []schema.Article) *articleCatalog {
+	cat := &articleCatalog{
+		byID:       make(map[int]schema.Article, len(articles)),
+		byBarcode:  make(map[string][]schema.Article),
+		successors: make(map[int]int),
+	}
+	for _, a := range articles {
+		cat.byID[a.ID] = a
+		if a.Barcode != nil {
+			cat.byBarcode[*a.Barcode] = append(cat.byBarcode[*a.Barcode], a)
+		}
+		if a.Precursor != nil {
+			cat.successors[a.Precursor.ID] = a.ID
+		}
+	}
+	return cat
+}
+
+// Returns the most recent version of an article, following successors
+// of superseded versions.
+func (cat *articleCatalog) current(id int) (schema.Article, bool) {
+	for i := 0; i < len(cat.successors); i++ {
+		next, ok := cat.successors[id]
+		if !ok {
+			break
+		}
+		id = next
+	}
+	a, ok := cat.byID[id]
+	return a, ok
+}
+
+// Returns the cached catalog, retrieving it if it is missing or stale.
+func (s *ArticleClient) catalog(ctx context.Context) (*articleCatalog, error) {
+	c := &s.client.articleCache
+
+	c.mu.Lock()
+	cat, fetched := c.catalog, c.fetched
+	c.mu.Unlock()
+
+	if cat != nil && (c.ttl <= 0 || time.Since(fetched) < c.ttl) {
+		return cat, nil
+	}
+	// use the catalog as retrieved; it may be invalidated meanwhile
+	cat, _, err := s.refreshCache(ctx)
+	return cat, err
+}
+
+// GET /article
+//
+// Retrieves the article catalog and stores it in the cache; see
+// WithArticleCache. Returns the response of the last page.
+func (s *ArticleClient) RefreshCache(ctx context.Context) (*Response, error) {
+	_, resp, err := s.refreshCache(ctx)
+	return resp, err
+}
+
+func (s *ArticleClient) refreshCache(ctx context.Context) (*articleCatalog, *Response, error) {
+	articles, resp, err := s.ListAll(ctx, nil)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	cat := newArticleCatalog(articles)
+	c := &s.client.articleCache
+	c.mu.Lock()
+	c.catalog, c.fetched = cat, time.Now()
+	c.mu.Unlock()
+
+	return cat, resp, nil
+}
+
+// Drops the cached catalog, forcing the next lookup to retrieve it
+// from the server.
+func (s *ArticleClient) InvalidateCache() {
+	c := &s.client.articleCache
+	c.mu.Lock()
+	c.catalog, c.fetched = nil, time.Time{}
+	c.mu.Unlock()
+}
+
+// GET /article/{articleId}
+//   - ErrorArticleNotFound
+//
+// Retrieves the most recent version of an article from the cache. IDs
+// of superseded versions map to their successors; see Update. Articles
+// missing from the cache are retrieved from the server as with Get, as
+// is everything if the cache is disabled.
+func (s *ArticleClient) Cached(ctx context.Context, id int) (*schema.Article, *Response, error) {
+	if !s.client.articleCache.enabled {
+		return s.Get(ctx, id)
+	}
+
+	cat, err := s.catalog(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a, ok := cat.current(id); ok {
+		return &a, nil, nil
+	}
+	return s.Get(ctx, id)
+}
+
+// Drops the cache if a failed purchase suggests that it is out of date.
+func (s *ArticleClient) invalidateOn(err error) {
+	switch ErrorClassOf(err) {
+	case schema.ErrorArticleNotFound, schema.ErrorArticleInactive:
+		s.InvalidateCache()
+	}
+}
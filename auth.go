@@ -0,0 +1,196 @@
+package strichliste
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Replaces credentials in logging and error output.
+const redacted = "[REDACTED]"
+
+type (
+	// Credentials authorize requests to the API endpoint. This is
+	// only necessary if the endpoint sits behind an authenticating
+	// reverse proxy; strichliste itself has no notion of authentication.
+	Credentials interface {
+		// Adds credentials to a request, usually via its headers.
+		// The request's context should be respected when doing
+		// anything expensive, like refreshing a token.
+		Authorize(req *http.Request) error
+	}
+
+	// HTTP basic authentication.
+	BasicAuth struct {
+		Username string
+		Password string
+	}
+
+	// A static bearer token.
+	BearerToken string
+
+	// A TokenSource supplies bearer tokens. It is consulted for every
+	// request, so implementations should cache tokens where possible.
+	TokenSource interface {
+		Token(ctx context.Context) (string, error)
+	}
+
+	// Adapts a function to the TokenSource interface.
+	TokenSourceFunc func(ctx context.Context) (string, error)
+
+	// A RefreshingTokenSource caches tokens obtained from Refresh
+	// until shortly before they expire.
+	RefreshingTokenSource struct {
+		// Obtains a new token and the time at which it expires.
+		// A zero expiry means the token never expires.
+		Refresh func(ctx context.Context) (token string, expiry time.Time, err error)
+
+		// Refresh tokens this long before they expire. Zero means
+		// a default of ten seconds.
+		Leeway time.Duration
+
+		mu     sync.Mutex
+		token  string
+		expiry time.Time
+	}
+
+	tokenCredentials struct {
+		source TokenSource
+	}
+)
+
+// Configure credentials for authorizing every request.
+// Not setting this option will send no credentials.
+func WithCredentials(credentials Credentials) ClientOption {
+	return func(client *Client) {
+		client.credentials = credentials
+	}
+}
+
+// Configure HTTP basic authentication for every request.
+func WithBasicAuth(username, password string) ClientOption {
+	return WithCredentials(BasicAuth{Username: username, Password: password})
+}
+
+// Configure a static bearer token for every request.
+func WithBearerToken(token string) ClientOption {
+	return WithCredentials(BearerToken(token))
+}
+
+// Configure a source of bearer tokens for every request.
+func WithTokenSource(source TokenSource) ClientOption {
+	return WithCredentials(tokenCredentials{source: source})
+}
+
+func (a BasicAuth) Authorize(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+func (a BasicAuth) String() string {
+	return "BasicAuth{" + a.Username + " " + redacted + "}"
+}
+
+func (t BearerToken) Authorize(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+func (t BearerToken) String() string {
+	return redacted
+}
+
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+func (c tokenCredentials) Authorize(req *http.Request) error {
+	token, err := c.source.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	return BearerToken(token).Authorize(req)
+}
+
+func (s *RefreshingTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	leeway := s.Leeway
+	if leeway == 0 {
+		leeway = 10 * time.Second
+	}
+
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Add(leeway).Before(s.expiry)) {
+		return s.token, nil
+	}
+
+	token, expiry, err := s.Refresh(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiry = token, expiry
+	return token, nil
+}
+
+// Drops the cached token, forcing a refresh on next use.
+func (s *RefreshingTokenSource) Invalidate() {
+	s.mu.Lock()
+	s.token = ""
+	s.mu.Unlock()
+}
+
+// Wraps a round trip so that each attempt is authorized. Credentials
+// are added to a copy of the request, so middleware never sees them.
+func authorize(rt RoundTripFunc, credentials Credentials) RoundTripFunc {
+	if credentials == nil {
+		return rt
+	}
+	return func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		if err := credentials.Authorize(req); err != nil {
+			return nil, err
+		}
+		return rt(req)
+	}
+}
+
+// Returns a copy of the header with credentials redacted,
+// suitable for logging.
+func RedactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, key := range []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"} {
+		if _, ok := h[key]; ok {
+			h.Set(key, redacted)
+		}
+	}
+	return h
+}
+
+// Returns the URL as string, with any password redacted.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	if u.User == nil {
+		return u.String()
+	}
+	if _, ok := u.User.Password(); !ok {
+		return u.String()
+	}
+	ru := *u
+	ru.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return ru.String()
+}
+
+// Redacts credentials contained in the URLs of transport errors.
+func redactError(err error) error {
+	if ue, ok := err.(*url.Error); ok {
+		if u, perr := url.Parse(ue.URL); perr == nil {
+			ue.URL = redactURL(u)
+		}
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+// Package bankimport turns incoming bank transfers into deposits.
+// Members pay their tab by bank transfer with their name in the
+// reference; this reads bank statements, matches the references to
+// users, and proposes deposits that can be reviewed and then booked.
+//
+//	entries, err := bankimport.ReadCAMT053(f)
+//	plan, err := bankimport.Propose(ctx, client, entries, nil)
+//	plan.Print(os.Stdout) // review
+//	results := plan.Book(ctx)
+//
+// Booking is idempotent: each deposit carries an idempotency key
+// derived from its statement entry, so importing the same statement
+// again, or overlapping statements, never credits a payment twice.
+//
+// The keys are found by listing the server's transactions. If the
+// server deletes reverted transactions, see
+// Settings.Payment.Reverse.Deletes, a deposit that was reverted can't
+// be found anymore, and importing its statement again books it again.
+// Plan.RevertsDelete reports this, and Plan.Print warns about it.
+package bankimport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"time"
+)
+
+// An Entry is a single booking of a bank statement.
+type Entry struct {
+	Date         time.Time // booking date
+	Amount       schema.Money
+	Currency     string // ISO 4217 code of the amount; empty means the server's
+	Reference    string // remittance information, e.g. "Strichliste jktr"
+	Counterparty string // name of the payer or payee
+	BankRef      string // the bank's unique reference, if known
+}
+
+// Derives the idempotency keys of entries. Keys are based on the
+// bank's reference if present, and on the entry's contents otherwise;
+// identical entries are told apart by their position among each other.
+func entryKeys(entries []Entry) []string {
+	keys := make([]string, len(entries))
+	seen := make(map[string]int)
+
+	for i, e := range entries {
+		material := "ref:" + e.BankRef
+		if e.BankRef == "" {
+			material = fmt.Sprintf("entry:%s|%d|%s|%s",
+				e.Date.Format("2006-01-02"), e.Amount, e.Reference, e.Counterparty)
+		}
+		seen[material]++
+		if n := seen[material]; n > 1 {
+			material = fmt.Sprintf("%s#%d", material, n)
+		}
+
+		sum := sha256.Sum256([]byte(material))
+		keys[i] = "bank-" + hex.EncodeToString(sum[:8])
+	}
+	return keys
+}
@@ -0,0 +1,41 @@
+package bankimport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntryKeys(t *testing.T) {
+	date := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	alice := Entry{Date: date, Amount: 1500, Reference: "Strichliste alice", Counterparty: "Alice Example"}
+	withRef := Entry{Date: date, Amount: 1500, Reference: "Strichliste alice", BankRef: "2020030112345"}
+
+	// keys of booked deposits must never change, or importing a
+	// statement again would book it again
+	keys := entryKeys([]Entry{alice, withRef})
+	if keys[0] != "bank-f946dd61f6a953c3" || keys[1] != "bank-c2c492ae5618afa3" {
+		t.Errorf("keys changed: %q", keys)
+	}
+
+	tests := []struct {
+		name    string
+		a, b    []Entry
+		i, j    int // indices of the keys to compare
+		sameKey bool
+	}{
+		{"same entry in another statement", []Entry{alice}, []Entry{withRef, alice}, 0, 1, true},
+		{"bank reference decides", []Entry{withRef}, []Entry{{BankRef: withRef.BankRef, Amount: 1}}, 0, 0, true},
+		{"currency doesn't matter", []Entry{alice}, []Entry{func() Entry { e := alice; e.Currency = "EUR"; return e }()}, 0, 0, true},
+		{"other amount", []Entry{alice}, []Entry{func() Entry { e := alice; e.Amount++; return e }()}, 0, 0, false},
+		{"other date", []Entry{alice}, []Entry{func() Entry { e := alice; e.Date = date.AddDate(0, 0, 1); return e }()}, 0, 0, false},
+		{"other reference", []Entry{alice}, []Entry{func() Entry { e := alice; e.Reference += "!"; return e }()}, 0, 0, false},
+		{"identical entries", []Entry{alice, alice}, []Entry{alice, alice}, 0, 1, false},
+		{"identical entries by position", []Entry{alice, alice}, []Entry{alice, withRef, alice}, 1, 2, true},
+	}
+	for _, tt := range tests {
+		ka, kb := entryKeys(tt.a), entryKeys(tt.b)
+		if got := ka[tt.i] == kb[tt.j]; got != tt.sameKey {
+			t.Errorf("%s: %q vs %q, want same = %v", tt.name, ka[tt.i], kb[tt.j], tt.sameKey)
+		}
+	}
+}
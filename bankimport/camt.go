@@ -0,0 +1,165 @@
+package bankimport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"strings"
+)
+
+// The parts of a CAMT.053 statement that matter here. Elements are
+// matched by local name, so any version of the schema works.
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount      camtMoney  `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	Status      camtStatus `xml:"Sts"`
+	BookingDate camtDate   `xml:"BookgDt"`
+	ValueDate   camtDate   `xml:"ValDt"`
+	BankRef     string     `xml:"AcctSvcrRef"`
+	Details     []struct {
+		Amount       camtMoney `xml:"Amt"`
+		CreditDebit  string    `xml:"CdtDbtInd"`
+		BankRef      string    `xml:"Refs>AcctSvcrRef"`
+		Unstructured []string  `xml:"RmtInf>Ustrd"`
+		Structured   []string  `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+		Debtor       string    `xml:"RltdPties>Dbtr>Nm"`
+		DebtorParty  string    `xml:"RltdPties>Dbtr>Pty>Nm"`
+		Creditor     string    `xml:"RltdPties>Cdtr>Nm"`
+		CreditorPty  string    `xml:"RltdPties>Cdtr>Pty>Nm"`
+	} `xml:"NtryDtls>TxDtls"`
+	Info string `xml:"AddtlNtryInf"`
+}
+
+// An amount along with its currency, like <Amt Ccy="EUR">1.50</Amt>.
+type camtMoney struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// Older versions have the status as text, newer ones as a code.
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) String() string {
+	if d.Date != "" {
+		return d.Date
+	}
+	if len(d.DateTime) >= 10 {
+		return d.DateTime[:10]
+	}
+	return ""
+}
+
+// Reads the entries of a CAMT.053 bank statement (ISO 20022 "bank to
+// customer statement"). Pending entries are skipped. Entries that
+// batch several transactions yield one Entry per transaction. Amounts
+// keep their currency; see Entry.Currency.
+func ReadCAMT053(r io.Reader) ([]Entry, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid statement: %w", err)
+	}
+
+	var entries []Entry
+	for _, stmt := range doc.Statements {
+		for _, n := range stmt.Entries {
+			status := strings.TrimSpace(firstOf(n.Status.Code, n.Status.Text))
+			if status == "PDNG" || status == "INFO" {
+				continue
+			}
+
+			date := n.BookingDate.String()
+			if date == "" {
+				date = n.ValueDate.String()
+			}
+			d, err := parseDate(date, "2006-01-02")
+			if err != nil {
+				return nil, err
+			}
+
+			if len(n.Details) <= 1 {
+				e := Entry{Date: d, BankRef: n.BankRef, Reference: n.Info, Currency: n.Amount.Currency}
+				if e.Amount, err = camtAmount(n.Amount.Value, n.CreditDebit); err != nil {
+					return nil, err
+				}
+				if len(n.Details) == 1 {
+					fillDetails(&e, n, 0)
+				}
+				entries = append(entries, e)
+				continue
+			}
+
+			for i, t := range n.Details {
+				if t.Amount.Value == "" {
+					return nil, fmt.Errorf("invalid statement: batch entry %s lacks transaction amounts", n.BankRef)
+				}
+				e := Entry{Date: d, Currency: firstOf(t.Amount.Currency, n.Amount.Currency)}
+				indicator := t.CreditDebit
+				if indicator == "" {
+					indicator = n.CreditDebit
+				}
+				if e.Amount, err = camtAmount(t.Amount.Value, indicator); err != nil {
+					return nil, err
+				}
+				fillDetails(&e, n, i)
+				if e.BankRef == "" && n.BankRef != "" {
+					e.BankRef = fmt.Sprintf("%s/%d", n.BankRef, i+1)
+				}
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries, nil
+}
+
+func fillDetails(e *Entry, n camtEntry, i int) {
+	t := n.Details[i]
+
+	if t.BankRef != "" {
+		e.BankRef = t.BankRef
+	}
+	if ref := strings.Join(append(t.Unstructured, t.Structured...), " "); ref != "" {
+		e.Reference = ref
+	}
+
+	// the counterparty is the debtor of credits and the creditor of debits
+	e.Counterparty = firstOf(t.Debtor, t.DebtorParty)
+	if e.Amount < 0 {
+		e.Counterparty = firstOf(t.Creditor, t.CreditorPty)
+	}
+	e.Counterparty = strings.TrimSpace(e.Counterparty)
+}
+
+func camtAmount(amount, indicator string) (schema.Money, error) {
+	m, err := schema.ParseMoney(amount)
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(indicator) == "DBIT" {
+		m = -m
+	}
+	return m, nil
+}
+
+func firstOf(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
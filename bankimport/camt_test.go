@@ -0,0 +1,90 @@
+package bankimport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const camtStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt><Stmt>
+  <Ntry>
+    <Amt Ccy="EUR">15.00</Amt>
+    <CdtDbtInd>CRDT</CdtDbtInd>
+    <Sts>BOOK</Sts>
+    <BookgDt><Dt>2020-03-01</Dt></BookgDt>
+    <AcctSvcrRef>REF1</AcctSvcrRef>
+    <NtryDtls><TxDtls>
+      <RmtInf><Ustrd>Strichliste alice</Ustrd></RmtInf>
+      <RltdPties><Dbtr><Nm>Alice Example</Nm></Dbtr></RltdPties>
+    </TxDtls></NtryDtls>
+  </Ntry>
+  <Ntry>
+    <Amt Ccy="EUR">4.20</Amt>
+    <CdtDbtInd>DBIT</CdtDbtInd>
+    <Sts><Cd>BOOK</Cd></Sts>
+    <BookgDt><DtTm>2020-03-02T10:00:00</DtTm></BookgDt>
+    <AddtlNtryInf>Bank fees</AddtlNtryInf>
+  </Ntry>
+  <Ntry>
+    <Amt Ccy="EUR">1.00</Amt>
+    <CdtDbtInd>CRDT</CdtDbtInd>
+    <Sts>PDNG</Sts>
+    <BookgDt><Dt>2020-03-03</Dt></BookgDt>
+  </Ntry>
+  <Ntry>
+    <Amt Ccy="CHF">30.00</Amt>
+    <CdtDbtInd>CRDT</CdtDbtInd>
+    <Sts>BOOK</Sts>
+    <ValDt><Dt>2020-03-04</Dt></ValDt>
+    <AcctSvcrRef>BATCH</AcctSvcrRef>
+    <NtryDtls>
+      <TxDtls>
+        <Amt Ccy="CHF">10.00</Amt>
+        <RmtInf><Ustrd>bob</Ustrd></RmtInf>
+      </TxDtls>
+      <TxDtls>
+        <Amt Ccy="CHF">20.00</Amt>
+        <Refs><AcctSvcrRef>BATCH-B</AcctSvcrRef></Refs>
+        <RmtInf><Strd><CdtrRefInf><Ref>jktr</Ref></CdtrRefInf></Strd></RmtInf>
+      </TxDtls>
+    </NtryDtls>
+  </Ntry>
+</Stmt></BkToCstmrStmt>
+</Document>`
+
+func TestReadCAMT053(t *testing.T) {
+	entries, err := ReadCAMT053(strings.NewReader(camtStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2020, 3, d, 0, 0, 0, 0, time.UTC) }
+	want := []Entry{
+		{Date: day(1), Amount: 1500, Currency: "EUR", Reference: "Strichliste alice", Counterparty: "Alice Example", BankRef: "REF1"},
+		{Date: day(2), Amount: -420, Currency: "EUR", Reference: "Bank fees"},
+		{Date: day(4), Amount: 1000, Currency: "CHF", Reference: "bob", BankRef: "BATCH/1"},
+		{Date: day(4), Amount: 2000, Currency: "CHF", Reference: "jktr", BankRef: "BATCH-B"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", entries, want)
+	}
+}
+
+func TestReadCAMT053Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"not XML", "Strichliste"},
+		{"invalid amount", `<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="EUR">1.500</Amt><BookgDt><Dt>2020-03-01</Dt></BookgDt></Ntry></Stmt></BkToCstmrStmt></Document>`},
+		{"missing date", `<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="EUR">1.50</Amt></Ntry></Stmt></BkToCstmrStmt></Document>`},
+	}
+	for _, tt := range tests {
+		if _, err := ReadCAMT053(strings.NewReader(tt.in)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package bankimport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// Date layouts tried when CSVOptions.DateLayout is empty.
+var dateLayouts = []string{"2006-01-02", "02.01.2006", "02.01.06", "01/02/2006"}
+
+// Describes the layout of a bank's CSV statements. Column names are
+// matched case-insensitively.
+type CSVOptions struct {
+	Date         string // column of the booking date; empty means "date"
+	Amount       string // column of the signed amount; empty means "amount"
+	Reference    string // column of the remittance information; empty means "reference"
+	Counterparty string // column of the payer's name; empty means "name"
+	BankRef      string // column of the bank's reference, if any
+	Currency     string // column of the amount's currency, if any
+
+	// Layout of dates as per time.Parse; empty means trying ISO 8601
+	// and the common German and US formats.
+	DateLayout string
+}
+
+// Reads the entries of a CSV bank statement. The first record is a
+// header naming the columns; see CSVOptions, which can be nil. Commas
+// and semicolons are accepted as separators, and amounts are parsed
+// with schema.ParseMoney.
+//
+// Many banks prepend some lines of account information; records
+// before the header are skipped.
+func ReadCSV(r io.Reader, opt *CSVOptions) ([]Entry, error) {
+	o := CSVOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	names := map[*string]string{&o.Date: "date", &o.Amount: "amount", &o.Reference: "reference", &o.Counterparty: "name"}
+	for field, def := range names {
+		if *field == "" {
+			*field = def
+		}
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(strings.NewReader(string(data)))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	if strings.Count(string(data), ";") > strings.Count(string(data), ",") {
+		cr.Comma = ';'
+	}
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid statement: %w", err)
+	}
+
+	// find the header
+	var columns map[string]int
+	for len(records) > 0 && columns == nil {
+		header := records[0]
+		records = records[1:]
+
+		c := make(map[string]int, len(header))
+		for i, h := range header {
+			c[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+		}
+		_, hasDate := c[strings.ToLower(o.Date)]
+		_, hasAmount := c[strings.ToLower(o.Amount)]
+		if hasDate && hasAmount {
+			columns = c
+		}
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("invalid statement: no header with columns %q and %q", o.Date, o.Amount)
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[strings.ToLower(name)]; ok && name != "" && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []Entry
+	for _, record := range records {
+		date, amount := field(record, o.Date), field(record, o.Amount)
+		if date == "" && amount == "" {
+			continue // trailing summary or blank lines
+		}
+
+		e := Entry{
+			Reference:    field(record, o.Reference),
+			Counterparty: field(record, o.Counterparty),
+			BankRef:      field(record, o.BankRef),
+			Currency:     strings.ToUpper(field(record, o.Currency)),
+		}
+		if e.Date, err = parseDate(date, o.DateLayout); err != nil {
+			return nil, err
+		}
+		if e.Amount, err = schema.ParseMoney(amount); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseDate(s, layout string) (time.Time, error) {
+	layouts := dateLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %q", s)
+}
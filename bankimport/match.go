@@ -0,0 +1,165 @@
+package bankimport
+
+import (
+	"github.com/jktr/go-strichliste/schema"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The similarity above which a user matches unless configured
+// otherwise; see Options.Threshold.
+const DefaultThreshold = 0.8
+
+// A Match is a user whose name was found in a reference.
+type Match struct {
+	User  schema.User
+	Score float64 // similarity between 0 and 1; 1 is exact
+}
+
+// Finds the users whose names appear in a reference, best match
+// first. Names match as whole words, ignoring case; names of at least
+// four characters also match misspelled or with separators left out,
+// as long as their similarity reaches the threshold.
+func matchUsers(reference string, users []schema.User, threshold float64) []Match {
+	ref := strings.ToLower(reference)
+	tokens := tokenize(ref)
+
+	var matches []Match
+	for _, u := range users {
+		if score := similarity(strings.ToLower(strings.TrimSpace(u.Name)), ref, tokens); score >= threshold {
+			matches = append(matches, Match{User: u, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// Splits a reference into words, keeping characters that are common
+// in user names, like dots and underscores.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("._-", r)
+	})
+}
+
+func similarity(name, ref string, tokens []string) float64 {
+	if name == "" {
+		return 0
+	}
+
+	// names may span several words, like "max mustermann"
+	if containsWord(ref, name) {
+		return 1
+	}
+	for _, t := range tokens {
+		if strings.Trim(t, "._-") == name {
+			return 1
+		}
+	}
+
+	if len([]rune(name)) < 4 {
+		return 0
+	}
+
+	best := 0.0
+	squashedName := squash(name)
+	for i, t := range tokens {
+		candidates := []string{t}
+		if i+1 < len(tokens) {
+			candidates = append(candidates, t+tokens[i+1])
+		}
+		for _, c := range candidates {
+			if squash(c) == squashedName {
+				return 0.95
+			}
+			if s := 1 - float64(levenshtein(name, c))/float64(maxLen(name, c)); s > best {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+// Reports whether s contains word, delimited by non-alphanumerics.
+func containsWord(s, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		if !isWordRune(lastRune(s[:start])) && !isWordRune(firstRune(s[end:])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return ' '
+}
+
+func lastRune(s string) rune {
+	r := []rune(s)
+	if len(r) == 0 {
+		return ' '
+	}
+	return r[len(r)-1]
+}
+
+// Drops everything but letters and digits.
+func squash(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isWordRune(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func maxLen(a, b string) int {
+	la, lb := len([]rune(a)), len([]rune(b))
+	if la > lb {
+		return la
+	}
+	return lb
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,84 @@
+package bankimport
+
+import (
+	"github.com/jktr/go-strichliste/schema"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMatchUsers(t *testing.T) {
+	var users []schema.User
+	for i, name := range []string{"jktr", "Max Mustermann", "alice", "alicia", "bob", "j.doe", "Müller"} {
+		users = append(users, schema.User{ID: i + 1, Name: name})
+	}
+
+	tests := []struct {
+		reference string
+		want      []string // names of the matches, best first
+	}{
+		{"Strichliste jktr", []string{"jktr"}},
+		{"STRICHLISTE JKTR", []string{"jktr"}},
+		{"jktr-strichliste", []string{"jktr"}},
+		{"Einzahlung max mustermann", []string{"Max Mustermann"}},
+		{"Einzahlung MaxMustermann", []string{"Max Mustermann"}},
+		{"Einzahlung Max Musterman", []string{"Max Mustermann"}},
+		{"for j.doe, thanks", []string{"j.doe"}},
+		{"Strichliste mueller", nil},
+		{"Strichliste Müller", []string{"Müller"}},
+		{"Strichliste alice", []string{"alice"}},
+		{"Strichliste alicja", []string{"alicia"}},
+		{"bob and alice", []string{"alice", "bob"}},
+		{"Strichliste jktrx", []string{"jktr"}},
+		{"Strichliste bobby", nil}, // short names match whole words only
+		{"Strichliste", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, m := range matchUsers(tt.reference, users, DefaultThreshold) {
+			got = append(got, m.User.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchUsers(%q) = %q, want %q", tt.reference, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name, ref string
+		want      float64
+	}{
+		{"alice", "alice", 1},
+		{"alice", "hi alice!", 1},
+		{"max mustermann", "maxmustermann", 0.95},
+		{"max mustermann", "max-mustermann", 0.95},
+		{"alice", "alicia", 1 - 2.0/6},
+		{"bob", "bobby", 0},
+		{"", "anything", 0},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.name, tt.ref, tokenize(tt.ref)); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.name, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"müller", "muller", 1},
+		{"flaw", "lawn", 2},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
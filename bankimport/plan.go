@@ -0,0 +1,313 @@
+package bankimport
+
+import (
+	"context"
+	"fmt"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// The state of a proposed deposit.
+type Status int
+
+const (
+	Proposed        Status = iota // a user was found; the deposit is booked
+	Unmatched                     // no user was found in the reference
+	Ambiguous                     // several users were found equally well
+	AlreadyBooked                 // the deposit exists from an earlier import
+	Ignored                       // the entry is no incoming payment
+	ForeignCurrency               // the entry's currency isn't the server's
+)
+
+func (s Status) String() string {
+	switch s {
+	case Proposed:
+		return "proposed"
+	case Unmatched:
+		return "unmatched"
+	case Ambiguous:
+		return "ambiguous"
+	case AlreadyBooked:
+		return "booked"
+	case Ignored:
+		return "ignored"
+	case ForeignCurrency:
+		return "currency"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+type (
+	// Options for Propose.
+	Options struct {
+		// The minimum similarity of fuzzy matches, between 0 and 1;
+		// zero means DefaultThreshold.
+		Threshold float64
+		// Prefix of the deposits' comments, followed by the booking
+		// date; empty means "Bank transfer".
+		Comment string
+	}
+
+	// A Proposal is a deposit derived from a statement entry.
+	//
+	// When reviewing, set User to book unmatched or ambiguous
+	// proposals anyway, or clear it to skip a proposal.
+	Proposal struct {
+		Entry Entry
+		Key   string // idempotency key; see strichliste.IdempotencyKey
+
+		Status     Status
+		User       *schema.User        // the user to credit
+		Candidates []Match             // the users found in the reference
+		Booked     *schema.Transaction // the existing deposit if AlreadyBooked
+	}
+
+	// A Plan lists proposed deposits. Print it for review, then Book it.
+	Plan struct {
+		client  *strichliste.Client
+		comment string
+		since   time.Time // where to stop searching for earlier imports
+
+		Proposals []Proposal
+
+		// Whether the server deletes reverted transactions. If so,
+		// reverted deposits of earlier imports can't be recognized,
+		// and are proposed again; review them with care.
+		RevertsDelete bool
+	}
+
+	// The outcome of booking a single proposal.
+	Result struct {
+		Proposal *Proposal
+
+		Transaction *schema.Transaction // the deposit
+		Existing    bool                // whether the deposit had been booked before
+		Err         error
+	}
+)
+
+// GET /settings
+// GET /user
+// GET /transaction
+//
+// Matches the references of incoming payments to the names of active
+// users, and proposes a deposit for each of them. Entries in another
+// currency than the server's are not proposed. Options can be nil.
+//
+// Users are matched locally rather than via UserClient.Search, since
+// the server only finds names that contain a query, whereas here the
+// query, i.e. the reference, contains the name, possibly misspelled.
+//
+// Transactions are searched for deposits booked by earlier imports, so
+// that overlapping statements can be imported safely. Since deposits
+// are booked after the payments arrived, the search stops at the date
+// of the earliest incoming payment, less a day for the server's
+// timezone. Deposits that were reverted count as booked as long as the
+// server still lists them. Servers that delete reverted transactions
+// don't, in which case such deposits are proposed again; see
+// Plan.RevertsDelete.
+func Propose(ctx context.Context, client *strichliste.Client, entries []Entry, opt *Options) (*Plan, error) {
+	o := Options{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Threshold == 0 {
+		o.Threshold = DefaultThreshold
+	}
+	if o.Comment == "" {
+		o.Comment = "Bank transfer"
+	}
+
+	settings, err := client.Settings.Cached(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currency := settings.I18n.Currency.Alpha3
+
+	all, _, err := client.User.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	var users []schema.User
+	for _, u := range all {
+		if u.IsActive {
+			users = append(users, u)
+		}
+	}
+
+	since := earliest(entries)
+	booked, err := bookedDeposits(ctx, client, since)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		client:        client,
+		comment:       o.Comment,
+		since:         since,
+		RevertsDelete: settings.Payment.Reverse.IsEnabled && settings.Payment.Reverse.Deletes,
+	}
+	for i, key := range entryKeys(entries) {
+		p := Proposal{Entry: entries[i], Key: key}
+
+		switch {
+		case p.Entry.Amount <= 0:
+			p.Status = Ignored
+		case p.Entry.Currency != "" && currency != "" && !strings.EqualFold(p.Entry.Currency, currency):
+			p.Status = ForeignCurrency
+		}
+		if p.Status != Proposed {
+			plan.Proposals = append(plan.Proposals, p)
+			continue
+		}
+
+		p.Candidates = matchUsers(p.Entry.Reference, users, o.Threshold)
+		switch {
+		case len(p.Candidates) == 0:
+			p.Status = Unmatched
+		case len(p.Candidates) > 1 && p.Candidates[1].Score >= p.Candidates[0].Score:
+			p.Status = Ambiguous
+		default:
+			p.Status = Proposed
+			u := p.Candidates[0].User
+			p.User = &u
+		}
+
+		if tx, ok := booked[key]; ok {
+			p.Status, p.Booked = AlreadyBooked, tx
+			p.User = &tx.Issuer
+		}
+		plan.Proposals = append(plan.Proposals, p)
+	}
+	return plan, nil
+}
+
+// Returns the date from which to search for deposits of the incoming
+// payments; zero if there are none, in which case nothing needs to be
+// searched.
+func earliest(entries []Entry) time.Time {
+	var first time.Time
+	for _, e := range entries {
+		if e.Amount > 0 && (first.IsZero() || e.Date.Before(first)) {
+			first = e.Date
+		}
+	}
+	if first.IsZero() {
+		return first
+	}
+	return first.AddDate(0, 0, -1)
+}
+
+// Collects the deposits created by imports since a date, by
+// idempotency key.
+func bookedDeposits(ctx context.Context, client *strichliste.Client, since time.Time) (map[string]*schema.Transaction, error) {
+	booked := make(map[string]*schema.Transaction)
+	if since.IsZero() {
+		return booked, nil
+	}
+
+	it := client.Transaction.ListIter(nil)
+	for it.Next(ctx) {
+		tx := it.Transaction()
+		if tx.TimeCreated.In(since.Location()).Before(since) {
+			break
+		}
+		if key, ok := strichliste.IdempotencyKey(&tx); ok && strings.HasPrefix(key, "bank-") {
+			booked[key] = &tx
+		}
+	}
+	return booked, it.Err()
+}
+
+// Prints the proposals as a table, for reviewing them.
+func (p *Plan) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "STATUS\tDATE\tAMOUNT\tUSER\tREFERENCE\n")
+
+	counts := make(map[Status]int)
+	for _, pr := range p.Proposals {
+		counts[pr.Status]++
+
+		user := "-"
+		if pr.User != nil {
+			user = pr.User.Name
+		} else if pr.Status == Ambiguous {
+			var names []string
+			for _, c := range pr.Candidates {
+				names = append(names, c.User.Name)
+			}
+			user = strings.Join(names, "|") + "?"
+		}
+
+		ref := strings.Join(strings.Fields(pr.Entry.Reference), " ")
+		if r := []rune(ref); len(r) > 40 {
+			ref = string(r[:39]) + "…"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", pr.Status,
+			pr.Entry.Date.Format("2006-01-02"), pr.Entry.Amount, user, ref)
+	}
+
+	fmt.Fprintf(tw, "\n%d proposed, %d unmatched, %d ambiguous, %d already booked, %d ignored, %d in another currency\n",
+		counts[Proposed], counts[Unmatched], counts[Ambiguous], counts[AlreadyBooked], counts[Ignored], counts[ForeignCurrency])
+	if p.RevertsDelete {
+		fmt.Fprintf(tw, "warning: the server deletes reverted transactions; reverted deposits of earlier imports are proposed again\n")
+	}
+	return tw.Flush()
+}
+
+// GET /transaction
+// POST /user/{userId}/transaction
+//
+// Books a deposit for each incoming payment that has a user, in plan
+// order; see Proposal. Transactions are searched for earlier imports
+// once more beforehand, as in Propose, and each deposit carries its proposal's
+// idempotency key, so booking the same proposal twice, even via
+// another plan, credits it only once. Failed deposits, e.g. due to
+// account boundaries, don't stop the remaining ones.
+//
+// Returns one result per booked or already booked proposal.
+func (p *Plan) Book(ctx context.Context) []Result {
+	var results []Result
+
+	booked, err := bookedDeposits(ctx, p.client, p.since)
+	if err != nil {
+		for i := range p.Proposals {
+			if pr := &p.Proposals[i]; pr.bookable() {
+				results = append(results, Result{Proposal: pr, Err: err})
+			}
+		}
+		return results
+	}
+
+	for i := range p.Proposals {
+		pr := &p.Proposals[i]
+		if !pr.bookable() && pr.Status != AlreadyBooked {
+			continue
+		}
+
+		r := Result{Proposal: pr}
+		if tx, ok := booked[pr.Key]; ok {
+			r.Transaction, r.Existing = tx, true
+		} else if pr.bookable() {
+			comment := fmt.Sprintf("%s %s", p.comment, pr.Entry.Date.Format("2006-01-02"))
+			r.Transaction, _, r.Err = p.client.Transaction.Context(pr.User.ID).
+				WithComment(comment).
+				WithIdempotencyKey(pr.Key).
+				Delta(ctx, int(pr.Entry.Amount))
+		} else {
+			continue // booked before, but no longer listed
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func (pr *Proposal) bookable() bool {
+	return pr.User != nil && pr.Entry.Amount > 0 &&
+		pr.Status != AlreadyBooked && pr.Status != Ignored && pr.Status != ForeignCurrency
+}
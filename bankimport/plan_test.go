@@ -0,0 +1,182 @@
+package bankimport
+
+import (
+	"bytes"
+	"context"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"github.com/jktr/go-strichliste/strichlistetest"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProposeAndBook(t *testing.T) {
+	ctx := context.Background()
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+	client := srv.NewClient()
+
+	alice := srv.AddUser("alice", 0)
+	srv.AddUser("alicia", 0)
+	srv.AddUser("bob", 0)
+	srv.AddUser("Bob", 0)
+
+	date := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Date: date, Amount: 1500, Currency: "EUR", Reference: "Strichliste alice", BankRef: "A"},
+		{Date: date, Amount: 1000, Reference: "Strichliste carol", BankRef: "B"},
+		{Date: date, Amount: 1000, Reference: "Strichliste bob", BankRef: "C"},
+		{Date: date, Amount: -420, Reference: "Bank fees", BankRef: "D"},
+		{Date: date, Amount: 2000, Currency: "CHF", Reference: "Strichliste alice", BankRef: "E"},
+	}
+	want := []Status{Proposed, Unmatched, Ambiguous, Ignored, ForeignCurrency}
+
+	plan, err := Propose(ctx, client, entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range plan.Proposals {
+		if p.Status != want[i] {
+			t.Errorf("proposal %d: status %s, want %s", i, p.Status, want[i])
+		}
+	}
+	if plan.RevertsDelete {
+		t.Error("RevertsDelete set, but the server keeps reverted transactions")
+	}
+
+	// foreign currencies aren't booked, even when assigned a user
+	plan.Proposals[4].User = &alice
+	results := plan.Book(ctx)
+	if len(results) != 1 || results[0].Err != nil || results[0].Existing {
+		t.Fatalf("results = %+v", results)
+	}
+	if u, _, _ := client.User.Get(ctx, alice.ID); u.Balance != 1500 {
+		t.Errorf("balance = %d, want 1500", u.Balance)
+	}
+
+	// booking the same plan again credits nothing
+	results = plan.Book(ctx)
+	if len(results) != 1 || !results[0].Existing {
+		t.Errorf("results = %+v, want the existing deposit", results)
+	}
+
+	// and neither does importing the statement again
+	plan, err = Propose(ctx, client, entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := plan.Proposals[0]; p.Status != AlreadyBooked || p.Booked == nil || p.User.ID != alice.ID {
+		t.Errorf("proposal after import: %+v", p)
+	}
+	plan.Book(ctx)
+	if u, _, _ := client.User.Get(ctx, alice.ID); u.Balance != 1500 {
+		t.Errorf("balance = %d after importing again, want 1500", u.Balance)
+	}
+
+	var out bytes.Buffer
+	if err := plan.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "0 proposed, 1 unmatched, 1 ambiguous, 1 already booked, 1 ignored, 1 in another currency") {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+}
+
+func TestProposeRevertsDelete(t *testing.T) {
+	ctx := context.Background()
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+	srv.UpdateSettings(func(s *schema.Settings) { s.Payment.Reverse.Deletes = true })
+	client := srv.NewClient()
+
+	srv.AddUser("alice", 0)
+	entries := []Entry{{Date: time.Now(), Amount: 1500, Reference: "alice"}}
+
+	plan, err := Propose(ctx, client, entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.RevertsDelete {
+		t.Error("RevertsDelete not set")
+	}
+	var out bytes.Buffer
+	plan.Print(&out)
+	if !strings.Contains(out.String(), "warning:") {
+		t.Errorf("no warning:\n%s", out.String())
+	}
+
+	// a reverted deposit vanishes, so it is proposed again
+	results := plan.Book(ctx)
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("results = %+v", results)
+	}
+	tx := results[0].Transaction
+	if _, _, err := client.Transaction.Context(tx.Issuer.ID).Revert(ctx, tx.ID); err != nil {
+		t.Fatal(err)
+	}
+	plan, err = Propose(ctx, client, entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Proposals[0].Status != Proposed {
+		t.Errorf("status = %s, want %s", plan.Proposals[0].Status, Proposed)
+	}
+}
+
+// Counts the requests for the system's transactions.
+type countTransactionLists int
+
+func (c *countTransactionLists) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/transaction") && !strings.Contains(req.URL.Path, "/user/") {
+		*c++
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestProposeSearchesSinceStatement(t *testing.T) {
+	ctx := context.Background()
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+
+	var lists countTransactionLists
+	client := srv.NewClient(strichliste.WithTransport(&lists))
+
+	// plenty of transactions from before the statement
+	alice := srv.AddUser("alice", 0)
+	srv.SetNow(func() time.Time { return time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC) })
+	for i := 0; i < 2*strichliste.DefaultPerPage; i++ {
+		if _, _, err := client.Transaction.Context(alice.ID).Delta(ctx, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.SetNow(nil)
+
+	entries := []Entry{{Date: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), Amount: 1500, Reference: "alice"}}
+	plan, err := Propose(ctx, client, entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results := plan.Book(ctx); len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("results = %+v", results)
+	}
+
+	lists = 0
+	plan, err = Propose(ctx, client, entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Proposals[0].Status != AlreadyBooked {
+		t.Errorf("status = %s, want %s", plan.Proposals[0].Status, AlreadyBooked)
+	}
+	if lists != 1 {
+		t.Errorf("listed transactions %d times, want once", lists)
+	}
+
+	// nothing to search without incoming payments
+	lists = 0
+	if _, err := Propose(ctx, client, []Entry{{Amount: -100}}, nil); err != nil || lists != 0 {
+		t.Errorf("listed transactions %d times without payments: %v", lists, err)
+	}
+}
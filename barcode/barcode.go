@@ -0,0 +1,288 @@
+// Package barcode validates and normalizes the retail barcodes that
+// articles usually carry: EAN-8, EAN-13, UPC-A, and UPC-E.
+//
+// UPC-A and UPC-E codes are subsets of EAN-13, so a product may be
+// scanned in either form; Normalize maps them to EAN-13 for comparison.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"strings"
+)
+
+// A Format is a barcode symbology.
+type Format int
+
+const (
+	Unknown Format = iota
+	EAN8
+	EAN13
+	UPCA
+	UPCE
+)
+
+func (f Format) String() string {
+	switch f {
+	case EAN8:
+		return "EAN-8"
+	case EAN13:
+		return "EAN-13"
+	case UPCA:
+		return "UPC-A"
+	case UPCE:
+		return "UPC-E"
+	}
+	return "unknown"
+}
+
+// The number of digits of a format, including the check digit.
+func (f Format) Len() int {
+	switch f {
+	case EAN8, UPCE:
+		return 8
+	case EAN13:
+		return 13
+	case UPCA:
+		return 12
+	}
+	return 0
+}
+
+var (
+	// The barcode has a length that matches no supported format.
+	ErrLength = errors.New("unsupported barcode length")
+	// The barcode contains something other than digits.
+	ErrDigits = errors.New("barcode contains non-digits")
+	// The barcode's check digit doesn't match.
+	ErrCheckDigit = errors.New("wrong barcode check digit")
+	// The barcode can't be converted to the requested format.
+	ErrConversion = errors.New("barcode not convertible")
+)
+
+// Detects the format of a barcode and validates its check digit.
+//
+// 8-digit codes are ambiguous: they are taken to be EAN-8 if their
+// check digit is valid as such, and UPC-E otherwise. Use ValidateAs if
+// the format is known.
+func Detect(code string) (Format, error) {
+	if !isDigits(code) {
+		return Unknown, fmt.Errorf("%q: %w", code, ErrDigits)
+	}
+
+	switch len(code) {
+	case 8:
+		if ValidateAs(code, EAN8) == nil {
+			return EAN8, nil
+		}
+		if err := ValidateAs(code, UPCE); err != nil {
+			return Unknown, err
+		}
+		return UPCE, nil
+	case 12:
+		return UPCA, ValidateAs(code, UPCA)
+	case 13:
+		return EAN13, ValidateAs(code, EAN13)
+	}
+	return Unknown, fmt.Errorf("%q: %w", code, ErrLength)
+}
+
+// Reports whether a barcode is valid in any supported format.
+func Validate(code string) error {
+	_, err := Detect(code)
+	return err
+}
+
+// Validates a barcode as a specific format.
+func ValidateAs(code string, f Format) error {
+	switch {
+	case !isDigits(code):
+		return fmt.Errorf("%q: %w", code, ErrDigits)
+	case f == Unknown || len(code) != f.Len():
+		return fmt.Errorf("%q is no %s: %w", code, f, ErrLength)
+	}
+
+	payload, check := code[:len(code)-1], code[len(code)-1]
+	if f == UPCE {
+		if code[0] != '0' && code[0] != '1' {
+			return fmt.Errorf("%q is no %s: %w", code, f, ErrConversion)
+		}
+		payload = expandUPCE(code)
+	}
+
+	if CheckDigit(payload) != check {
+		return fmt.Errorf("%q: %w", code, ErrCheckDigit)
+	}
+	return nil
+}
+
+// Computes the check digit of a barcode's payload, i.e. of all its
+// digits but the check digit itself. The payload must consist of
+// digits only.
+func CheckDigit(payload string) byte {
+	sum := 0
+	for i := 0; i < len(payload); i++ {
+		d := int(payload[len(payload)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Expands a UPC-E code to the payload of its UPC-A form.
+func expandUPCE(code string) string {
+	ns, x, last := code[:1], code[1:6], code[6]
+	switch last {
+	case '0', '1', '2':
+		return ns + x[:2] + string(last) + "0000" + x[2:5]
+	case '3':
+		return ns + x[:3] + "00000" + x[3:5]
+	case '4':
+		return ns + x[:4] + "00000" + x[4:5]
+	}
+	return ns + x[:5] + "0000" + string(last)
+}
+
+// Converts a valid barcode to EAN-13. UPC-A codes gain a leading zero,
+// and UPC-E codes are expanded first. EAN-8 codes can't be converted.
+func ToEAN13(code string) (string, error) {
+	f, err := Detect(code)
+	if err != nil {
+		return "", err
+	}
+	switch f {
+	case EAN13:
+		return code, nil
+	case UPCA:
+		return "0" + code, nil
+	case UPCE:
+		return "0" + expandUPCE(code) + code[7:], nil
+	}
+	return "", fmt.Errorf("%s %q to %s: %w", f, code, EAN13, ErrConversion)
+}
+
+// Converts a valid barcode to UPC-A. EAN-13 codes must start with a
+// zero, and UPC-E codes are expanded. EAN-8 codes can't be converted.
+func ToUPCA(code string) (string, error) {
+	f, err := Detect(code)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case f == UPCA:
+		return code, nil
+	case f == EAN13 && code[0] == '0':
+		return code[1:], nil
+	case f == UPCE:
+		return expandUPCE(code) + code[7:], nil
+	}
+	return "", fmt.Errorf("%s %q to %s: %w", f, code, UPCA, ErrConversion)
+}
+
+// Converts a valid barcode to UPC-E, if it has enough zeros to be
+// compressed.
+func ToUPCE(code string) (string, error) {
+	upca, err := ToUPCA(code)
+	if err != nil {
+		return "", err
+	}
+
+	ns, m, p, check := upca[:1], upca[1:6], upca[6:11], upca[11:]
+	if ns != "0" && ns != "1" {
+		return "", fmt.Errorf("%q to %s: %w", code, UPCE, ErrConversion)
+	}
+
+	var x string
+	switch {
+	case m[2] <= '2' && m[3:] == "00" && p[:2] == "00":
+		x = m[:2] + p[2:] + m[2:3]
+	case m[3:] == "00" && p[:3] == "000":
+		x = m[:3] + p[3:] + "3"
+	case m[4] == '0' && p[:4] == "0000":
+		x = m[:4] + p[4:] + "4"
+	case p[:4] == "0000" && p[4] >= '5':
+		x = m + p[4:]
+	default:
+		return "", fmt.Errorf("%q to %s: %w", code, UPCE, ErrConversion)
+	}
+	return ns + x + check, nil
+}
+
+// Returns the canonical form of a barcode for comparison: EAN-13 for
+// UPC-A, UPC-E, and EAN-13 codes, and EAN-8 codes as they are.
+// Surrounding whitespace is ignored.
+func Normalize(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	f, err := Detect(code)
+	if err != nil {
+		return "", err
+	}
+	if f == EAN8 {
+		return code, nil
+	}
+	return ToEAN13(code)
+}
+
+// Returns the forms in which a valid barcode may be written: the code
+// itself, followed by its EAN-13, UPC-A, and UPC-E forms, as far as it
+// is convertible to them. Invalid barcodes only have their own form.
+func Forms(code string) []string {
+	code = strings.TrimSpace(code)
+	forms := []string{code}
+	for _, convert := range []func(string) (string, error){ToEAN13, ToUPCA, ToUPCE} {
+		f, err := convert(code)
+		if err != nil {
+			continue
+		}
+		dup := false
+		for _, g := range forms {
+			dup = dup || g == f
+		}
+		if !dup {
+			forms = append(forms, f)
+		}
+	}
+	return forms
+}
+
+// Reports whether two barcodes denote the same product. Invalid
+// barcodes are compared verbatim.
+func Equal(a, b string) bool {
+	if na, err := Normalize(a); err == nil {
+		a = na
+	}
+	if nb, err := Normalize(b); err == nil {
+		b = nb
+	}
+	return a == b
+}
+
+// Returns the active articles whose barcodes denote the same product
+// as the passed one, in any form; see Equal. Checking this before
+// creating or updating an article avoids
+// schema.ErrorArticleBarcodeAlreadyExists, and also catches duplicates
+// the server can't, like a UPC-A code next to its EAN-13 form.
+func Duplicates(code string, articles []schema.Article) []schema.Article {
+	var dups []schema.Article
+	for _, a := range articles {
+		if a.IsActive && a.Barcode != nil && Equal(*a.Barcode, code) {
+			dups = append(dups, a)
+		}
+	}
+	return dups
+}
@@ -0,0 +1,212 @@
+package barcode
+
+import (
+	"errors"
+	"github.com/jktr/go-strichliste/schema"
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		code string
+		want Format
+		err  error
+	}{
+		{"96385074", EAN8, nil},
+		{"4006381333931", EAN13, nil},
+		{"4029764001807", EAN13, nil},
+		{"036000291452", UPCA, nil},
+		{"042100005264", UPCA, nil},
+		{"04252614", UPCE, nil},
+		{"01234531", UPCE, nil},
+		{"01234565", EAN8, nil}, // valid in both formats
+
+		{"96385075", Unknown, ErrConversion},
+		{"4006381333932", EAN13, ErrCheckDigit},
+		{"036000291453", UPCA, ErrCheckDigit},
+		{"04252615", Unknown, ErrCheckDigit},
+		{"24252615", Unknown, ErrConversion},
+		{"1234567", Unknown, ErrLength},
+		{"", Unknown, ErrDigits},
+		{"4006381-33393", Unknown, ErrDigits},
+		{" 96385074", Unknown, ErrDigits},
+	}
+	for _, tt := range tests {
+		got, err := Detect(tt.code)
+		if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+			t.Errorf("Detect(%q): err = %v, want %v", tt.code, err, tt.err)
+		}
+		if got != tt.want {
+			t.Errorf("Detect(%q) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAs(t *testing.T) {
+	tests := []struct {
+		code   string
+		format Format
+		err    error
+	}{
+		{"04252614", UPCE, nil},
+		{"04252614", EAN8, ErrCheckDigit},
+		{"01234565", UPCE, nil},
+		{"96385074", EAN8, nil},
+		{"036000291452", EAN13, ErrLength},
+		{"036000291452", Unknown, ErrLength},
+	}
+	for _, tt := range tests {
+		err := ValidateAs(tt.code, tt.format)
+		if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+			t.Errorf("ValidateAs(%q, %s): err = %v, want %v", tt.code, tt.format, err, tt.err)
+		}
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    byte
+	}{
+		{"9638507", '4'},
+		{"400638133393", '1'},
+		{"03600029145", '2'},
+		{"04210000526", '4'},
+		{"000000000000", '0'},
+	}
+	for _, tt := range tests {
+		if got := CheckDigit(tt.payload); got != tt.want {
+			t.Errorf("CheckDigit(%q) = %c, want %c", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		code              string
+		ean13, upca, upce string // empty if not convertible
+	}{
+		{"036000291452", "0036000291452", "036000291452", ""},
+		{"0036000291452", "0036000291452", "036000291452", ""},
+		{"04252614", "0042100005264", "042100005264", "04252614"},
+		{"042100005264", "0042100005264", "042100005264", "04252614"},
+		{"01234531", "0012300000451", "012300000451", "01234531"},
+		{"012345000065", "0012345000065", "012345000065", "01234565"},
+		{"4006381333931", "4006381333931", "", ""},
+		{"96385074", "", "", ""},
+	}
+	convert := func(f func(string) (string, error), code string) string {
+		s, err := f(code)
+		if err != nil {
+			if !errors.Is(err, ErrConversion) {
+				t.Errorf("%q: unexpected error %v", code, err)
+			}
+			return ""
+		}
+		return s
+	}
+	for _, tt := range tests {
+		if got := convert(ToEAN13, tt.code); got != tt.ean13 {
+			t.Errorf("ToEAN13(%q) = %q, want %q", tt.code, got, tt.ean13)
+		}
+		if got := convert(ToUPCA, tt.code); got != tt.upca {
+			t.Errorf("ToUPCA(%q) = %q, want %q", tt.code, got, tt.upca)
+		}
+		if got := convert(ToUPCE, tt.code); got != tt.upce {
+			t.Errorf("ToUPCE(%q) = %q, want %q", tt.code, got, tt.upce)
+		}
+	}
+}
+
+func TestRoundTrips(t *testing.T) {
+	for _, upce := range []string{"04252614", "01234531", "01234543", "06543217", "09876505"} {
+		if err := ValidateAs(upce, UPCE); err != nil {
+			t.Errorf("%q: %v", upce, err)
+			continue
+		}
+		upca, err := ToUPCA(upce)
+		if err != nil {
+			t.Errorf("ToUPCA(%q): %v", upce, err)
+			continue
+		}
+		if back, err := ToUPCE(upca); err != nil || back != upce {
+			t.Errorf("ToUPCE(ToUPCA(%q)) = %q, %v", upce, back, err)
+		}
+		ean13, err := ToEAN13(upce)
+		if err != nil {
+			t.Errorf("ToEAN13(%q): %v", upce, err)
+			continue
+		}
+		if back, err := ToUPCA(ean13); err != nil || back != upca {
+			t.Errorf("ToUPCA(ToEAN13(%q)) = %q, %v; want %q", upce, back, err, upca)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"036000291452", "0036000291452", true},
+		{"04252614", "042100005264", true},
+		{"04252614", " 0042100005264 ", true},
+		{"96385074", "96385074", true},
+		{"96385074", "0000096385074", false},
+		{"4006381333931", "036000291452", false},
+		{"invalid", "invalid", true},
+		{"invalid", "036000291452", false},
+	}
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestForms(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{"04252614", []string{"04252614", "0042100005264", "042100005264"}},
+		{"036000291452", []string{"036000291452", "0036000291452"}},
+		{"4006381333931", []string{"4006381333931"}},
+		{"96385074", []string{"96385074"}},
+		{"12345", []string{"12345"}},
+	}
+	for _, tt := range tests {
+		if got := Forms(tt.code); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Forms(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	code := func(s string) *string { return &s }
+	articles := []schema.Article{
+		{ID: 1, Barcode: code("0036000291452"), IsActive: true},
+		{ID: 2, Barcode: code("036000291452"), IsActive: false},
+		{ID: 3, Barcode: code("042100005264"), IsActive: true},
+		{ID: 4, IsActive: true},
+	}
+
+	tests := []struct {
+		code string
+		want []int
+	}{
+		{"036000291452", []int{1}},
+		{"04252614", []int{3}},
+		{"4006381333931", nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, a := range Duplicates(tt.code, articles) {
+			got = append(got, a.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Duplicates(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
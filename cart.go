@@ -0,0 +1,257 @@
+package strichliste
+
+import (
+	"context"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"time"
+)
+
+// How long reverting the purchases of a failed checkout may take. The
+// rollback runs even if the checkout's context was canceled.
+const RollbackTimeout = 30 * time.Second
+
+type (
+	// A Cart collects articles that a user purchases together. Checking
+	// out creates one transaction per item; if any of them fails, the
+	// ones already created are reverted.
+	//
+	//	outcome, err := client.Transaction.Context(user).Cart().
+	//		AddArticle(mate, 2).
+	//		AddBarcode("4029764001807", 1).
+	//		Checkout(ctx)
+	Cart struct {
+		tc    *TransactionContext
+		items []cartItem
+	}
+
+	cartItem struct {
+		articleID int    // zero if identified by barcode
+		barcode   string // empty if identified by ID
+		quantity  int
+	}
+
+	// A CartLine is an item of a cart, with its article resolved.
+	CartLine struct {
+		Article  schema.Article
+		Quantity int
+
+		Transaction *schema.Transaction // the purchase, once created or found
+		Reverted    *schema.Transaction // the reverted purchase, if rolled back
+		RevertErr   error               // why rolling back failed, if it did
+	}
+
+	// The outcome of checking out a cart.
+	CheckoutOutcome struct {
+		Lines []CartLine
+
+		// Index of the line whose purchase failed, or -1 on success.
+		FailedAt int
+		// Why the checkout failed; nil on success.
+		Err error
+		// Whether all purchases were reverted after a failure,
+		// including the failed one if it was created nevertheless;
+		// if not, check RevertErr of each line.
+		Compensated bool
+	}
+
+	// A context that keeps the values of its parent, but not its
+	// cancellation or deadline.
+	detachedContext struct {
+		parent context.Context
+	}
+)
+
+// Create an empty cart for purchases by the current user.
+// Any comment of the context applies to all purchases of the cart.
+// Each purchase gets an idempotency key of its own, derived from the
+// context's key, or from a fresh one per checkout if there is none.
+func (c *TransactionContext) Cart() *Cart {
+	return &Cart{tc: c}
+}
+
+// Adds a number of articles by ID.
+func (c *Cart) AddArticle(id int, quantity int) *Cart {
+	c.items = append(c.items, cartItem{articleID: id, quantity: quantity})
+	return c
+}
+
+// Adds a number of articles by barcode; see ArticleClient.GetByBarcode.
+func (c *Cart) AddBarcode(barcode string, quantity int) *Cart {
+	c.items = append(c.items, cartItem{barcode: barcode, quantity: quantity})
+	return c
+}
+
+// Retrieves the articles of the cart.
+func (c *Cart) Resolve(ctx context.Context) ([]CartLine, error) {
+	lines := make([]CartLine, 0, len(c.items))
+	for _, item := range c.items {
+		if item.quantity < 1 {
+			return nil, fmt.Errorf("invalid quantity %d: %w", item.quantity, schema.ErrorParameterInvalid)
+		}
+
+		var a *schema.Article
+		var err error
+		if item.barcode != "" {
+			a, _, err = c.tc.client.Article.GetByBarcode(ctx, item.barcode)
+		} else {
+			a, _, err = c.tc.client.Article.Cached(ctx, item.articleID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, CartLine{Article: *a, Quantity: item.quantity})
+	}
+	return lines, nil
+}
+
+// Returns the total price of the cart.
+func (c *Cart) Total(ctx context.Context) (int, error) {
+	lines, err := c.Resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return cartTotal(lines), nil
+}
+
+// Predicts whether checking out would fail, based on the user's current
+// balance and the cached settings; see Validator. Returns nil if there
+// are no violations.
+func (c *Cart) Check(ctx context.Context) ([]Violation, error) {
+	lines, err := c.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v, err := c.tc.client.Settings.Validator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, _, err := c.tc.client.User.Get(ctx, c.tc.issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	// each purchase sees the balance left by the previous ones
+	var vs []Violation
+	for _, line := range lines {
+		vs = append(vs, v.Purchase(user, &line.Article, line.Quantity)...)
+		user.Balance -= line.Article.Value * line.Quantity
+	}
+	return vs, nil
+}
+
+// Purchases the cart's articles, one transaction per item. If any
+// purchase fails, the already created ones are reverted in reverse
+// order. If the failed purchase may have been created nevertheless,
+// e.g. due to a timeout, it is searched by its idempotency key and
+// reverted as well. Reverting isn't affected by the cancellation of
+// ctx, but limited to RollbackTimeout. The returned error is the
+// outcome's Err.
+//
+// Checking out again with the context's idempotency key keeps the
+// purchases that weren't reverted, and makes the reverted ones again.
+//
+// Returns a nil outcome if the articles couldn't be resolved, in which
+// case nothing was purchased.
+func (c *Cart) Checkout(ctx context.Context) (*CheckoutOutcome, error) {
+	lines, err := c.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := &CheckoutOutcome{Lines: lines, FailedAt: -1}
+
+	key := c.tc.idempotencyKey
+	if key == "" {
+		key = NewIdempotencyKey()
+	}
+
+	for i := range lines {
+		line := &lines[i]
+
+		// each purchase needs a key of its own
+		tc := c.tc.WithIdempotencyKey(fmt.Sprintf("%s-%d", key, i))
+
+		quantity := line.Quantity
+		line.Transaction, _, err = tc.createPurchase(ctx, &schema.TransactionCreateRequest{
+			Amount:    -line.Article.Value * quantity,
+			ArticleID: &line.Article.ID,
+			Quantity:  &quantity,
+		})
+		if err != nil {
+			outcome.FailedAt, outcome.Err = i, err
+			outcome.Compensated = c.rollback(ctx, lines[:i+1], tc, err)
+			return outcome, err
+		}
+	}
+
+	return outcome, nil
+}
+
+// Reverts the purchases of the lines in reverse order; reports whether
+// all of them were reverted. The last line is the one whose purchase
+// failed with err, using the failed context.
+func (c *Cart) rollback(ctx context.Context, lines []CartLine, failed *TransactionContext, err error) bool {
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, RollbackTimeout)
+	defer cancel()
+
+	ok := true
+
+	// the failed purchase may have landed anyway
+	if last := &lines[len(lines)-1]; isAmbiguous(err) {
+		tx, _, ferr := failed.findByIdempotencyKey(ctx, failed.idempotencyKey)
+		if ferr != nil {
+			last.RevertErr = fmt.Errorf("purchase may have been created: %w", ferr)
+			ok = false
+		}
+		last.Transaction = tx
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := &lines[i]
+		if line.Transaction == nil {
+			continue
+		}
+		line.Reverted, _, line.RevertErr = c.tc.Revert(ctx, line.Transaction.ID)
+		if line.RevertErr != nil {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Returns the total price of the purchases that were made and not
+// reverted.
+func (o *CheckoutOutcome) Total() int {
+	total := 0
+	for _, line := range o.Lines {
+		if line.Transaction != nil && line.Reverted == nil && !line.Transaction.IsReversed {
+			total -= line.Transaction.Value
+		}
+	}
+	return total
+}
+
+func cartTotal(lines []CartLine) int {
+	total := 0
+	for _, line := range lines {
+		total += line.Article.Value * line.Quantity
+	}
+	return total
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
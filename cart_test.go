@@ -0,0 +1,153 @@
+package strichliste_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"github.com/jktr/go-strichliste/strichlistetest"
+	"net/http"
+	"testing"
+)
+
+// Cancels a context once a number of purchases have been created,
+// optionally losing the response to the last one.
+type cancelAfterPurchase struct {
+	cancel context.CancelFunc
+	after  int
+	lose   bool
+}
+
+func (c *cancelAfterPurchase) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if req.Method == http.MethodPost {
+		if c.after--; c.after == 0 {
+			c.cancel()
+			if c.lose && err == nil {
+				resp.Body.Close()
+				return nil, context.Canceled
+			}
+		}
+	}
+	return resp, err
+}
+
+func TestCheckout(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     int
+		cancelAfter int  // purchases after which the context is canceled
+		lose        bool // whether the last purchase's response is lost
+		err         error
+		failedAt    int
+		compensated bool
+		wantBalance int
+	}{
+		{"success", 1000, 0, false, nil, -1, false, 1000 - 300 - 80},
+		{"account boundary", -19650, 0, false, schema.ErrorAccountBalanceBoundary, 1, true, -19650},
+		{"canceled", 1000, 1, false, context.Canceled, 1, true, 1000},
+		{"canceled during purchase", 1000, 2, true, context.Canceled, 1, true, 1000},
+	}
+	for _, tt := range tests {
+		srv := strichlistetest.NewServer()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		client := srv.NewClient()
+		if tt.cancelAfter > 0 {
+			client = srv.NewClient(strichliste.WithTransport(&cancelAfterPurchase{cancel, tt.cancelAfter, tt.lose}))
+		}
+
+		u := srv.AddUser("alice", tt.balance)
+		mate := srv.AddArticle("Club Mate", 150, "")
+		coffee := srv.AddArticle("Coffee", 80, "")
+		water := srv.AddArticle("Water", 0, "") // free articles are fine
+
+		cart := client.Transaction.Context(u.ID).Cart().
+			AddArticle(mate.ID, 2).
+			AddArticle(coffee.ID, 1).
+			AddArticle(water.ID, 1)
+
+		if vs, err := cart.Check(ctx); err != nil || (tt.err == nil && len(vs) != 0) {
+			t.Errorf("%s: violations %v, %v", tt.name, vs, err)
+		}
+
+		outcome, err := cart.Checkout(ctx)
+		if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+		if outcome.FailedAt != tt.failedAt || outcome.Compensated != tt.compensated {
+			t.Errorf("%s: failed at %d, compensated %v", tt.name, outcome.FailedAt, outcome.Compensated)
+		}
+		for i, line := range outcome.Lines {
+			if line.RevertErr != nil {
+				t.Errorf("%s: line %d: %v", tt.name, i, line.RevertErr)
+			}
+		}
+		if tt.lose && outcome.Lines[tt.failedAt].Reverted == nil {
+			t.Errorf("%s: the lost purchase wasn't reverted", tt.name)
+		}
+
+		got, _, err := srv.NewClient().User.Get(context.Background(), u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Balance != tt.wantBalance || tt.balance-outcome.Total() != tt.wantBalance {
+			t.Errorf("%s: balance %d, total %d, want balance %d", tt.name, got.Balance, outcome.Total(), tt.wantBalance)
+		}
+
+		cancel()
+		srv.Close()
+	}
+}
+
+func TestCheckoutRetryAfterCompensation(t *testing.T) {
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	client := srv.NewClient()
+
+	u := srv.AddUser("alice", -19650)
+	mate := srv.AddArticle("Club Mate", 150, "")
+	coffee := srv.AddArticle("Coffee", 80, "")
+
+	cart := client.Transaction.Context(u.ID).WithIdempotencyKey("retry").Cart().
+		AddArticle(mate.ID, 2).
+		AddArticle(coffee.ID, 1)
+
+	outcome, err := cart.Checkout(ctx)
+	if !errors.Is(err, schema.ErrorAccountBalanceBoundary) || !outcome.Compensated {
+		t.Fatalf("first checkout: err = %v, compensated %v", err, outcome.Compensated)
+	}
+
+	srv.UpdateSettings(func(s *schema.Settings) {
+		s.Account.Limit.Lower = -30000
+	})
+
+	// the reverted purchase of the first line must be made again
+	outcome, err = cart.Checkout(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, line := range outcome.Lines {
+		if line.Transaction == nil || line.Transaction.IsReversed {
+			t.Errorf("line %d: not purchased: %+v", i, line.Transaction)
+		}
+	}
+
+	got, _, err := client.User.Get(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Balance != -19650-380 || outcome.Total() != 380 {
+		t.Errorf("balance %d, total %d, want balance %d, total 380", got.Balance, outcome.Total(), -19650-380)
+	}
+
+	// retrying a successful checkout purchases nothing
+	outcome, err = cart.Checkout(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := client.User.Get(ctx, u.ID); got.Balance != -19650-380 {
+		t.Errorf("repeated checkout charged again: balance %d", got.Balance)
+	}
+}
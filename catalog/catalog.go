@@ -0,0 +1,63 @@
+// Package catalog reconciles the server's articles with a desired
+// catalog, e.g. a drinks list maintained in a spreadsheet.
+//
+//	items, err := catalog.ReadCSV(f)
+//	plan, err := catalog.Diff(ctx, client, items, nil)
+//	plan.Print(os.Stdout) // dry run
+//	results := plan.Apply(ctx)
+package catalog
+
+import (
+	"fmt"
+	"github.com/jktr/go-strichliste/barcode"
+	"github.com/jktr/go-strichliste/schema"
+	"strings"
+)
+
+// An Item is an article as it should exist on the server.
+type Item struct {
+	Name    string
+	Value   schema.Money
+	Barcode string // empty leaves an existing barcode untouched
+}
+
+// Checks the items for missing names, negative prices, and duplicate
+// names or barcodes. Free items are fine. Barcodes are duplicates if
+// they denote the same product; see barcode.Equal.
+func validate(items []Item) error {
+	names := make(map[string]bool, len(items))
+	barcodes := make(map[string]bool, len(items))
+
+	for i, item := range items {
+		name := normalizeName(item.Name)
+		switch {
+		case name == "":
+			return fmt.Errorf("item %d: missing name", i+1)
+		case item.Value < 0:
+			return fmt.Errorf("item %d (%s): price must not be negative", i+1, item.Name)
+		case names[name]:
+			return fmt.Errorf("item %d (%s): duplicate name", i+1, item.Name)
+		case item.Barcode != "" && barcodes[normalizeBarcode(item.Barcode)]:
+			return fmt.Errorf("item %d (%s): duplicate barcode %s", i+1, item.Name, item.Barcode)
+		}
+		names[name] = true
+		if item.Barcode != "" {
+			barcodes[normalizeBarcode(item.Barcode)] = true
+		}
+	}
+	return nil
+}
+
+// Names match case-insensitively and regardless of surrounding space.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Barcodes match if they denote the same product; invalid ones match
+// verbatim. See barcode.Normalize.
+func normalizeBarcode(code string) string {
+	if n, err := barcode.Normalize(code); err == nil {
+		return n
+	}
+	return code
+}
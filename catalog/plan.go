@@ -0,0 +1,275 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/barcode"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// What to do with an article.
+type Action int
+
+const (
+	Create Action = iota
+	Update
+	Deactivate
+)
+
+func (a Action) String() string {
+	switch a {
+	case Create:
+		return "create"
+	case Update:
+		return "update"
+	case Deactivate:
+		return "deactivate"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+type (
+	// Options for Diff.
+	Options struct {
+		// Deactivate active articles missing from the items. By
+		// default, they are left alone, so that a partial list never
+		// deactivates articles by accident.
+		DeactivateUnlisted bool
+	}
+
+	// A Change brings a single article in line with the items.
+	Change struct {
+		Action  Action
+		Item    *Item           // the desired state; nil when deactivating
+		Article *schema.Article // the current state; nil when creating
+		Fields  []string        // names of the fields that differ when updating
+	}
+
+	// A Plan lists the changes needed to reconcile the server's articles
+	// with the items. Print it for a dry run, then Apply it.
+	Plan struct {
+		client *strichliste.Client
+
+		Changes   []Change // deactivations, then updates, then creations
+		Unchanged int      // number of articles that already match
+	}
+
+	// The outcome of applying a single change.
+	Result struct {
+		Change Change
+
+		// The article after the change. When updating an article that
+		// transactions reference, the server replaces it with a new
+		// version instead; see Replaced.
+		Article *schema.Article
+		Err     error
+	}
+)
+
+// GET /article
+//
+// Compares the active articles against the items, and plans the
+// changes needed to make them match. Options can be nil.
+//
+// Items are matched to active articles by barcode first, in any of its
+// forms (see barcode.Equal), and then by name, ignoring case. Inactive articles are never reactivated;
+// unmatched items create new articles instead. Active articles missing
+// from the items are kept unless Options.DeactivateUnlisted is set.
+func Diff(ctx context.Context, client *strichliste.Client, items []Item, opt *Options) (*Plan, error) {
+	if err := validate(items); err != nil {
+		return nil, err
+	}
+	articles, _, err := client.Article.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	plan := DiffArticles(articles, items, opt)
+	plan.client = client
+	return plan, nil
+}
+
+// Like Diff, but compares against the passed articles. The resulting
+// plan can only be printed, not applied.
+func DiffArticles(articles []schema.Article, items []Item, opt *Options) *Plan {
+	if opt == nil {
+		opt = &Options{}
+	}
+
+	var active []*schema.Article
+	for i := range articles {
+		if articles[i].IsActive {
+			active = append(active, &articles[i])
+		}
+	}
+
+	matched := make(map[*schema.Article]bool)
+	match := make([]*schema.Article, len(items))
+
+	// barcodes are unique among active articles
+	for i, item := range items {
+		if item.Barcode == "" {
+			continue
+		}
+		for _, a := range active {
+			if a.Barcode != nil && barcode.Equal(*a.Barcode, item.Barcode) && !matched[a] {
+				match[i], matched[a] = a, true
+				break
+			}
+		}
+	}
+	for i, item := range items {
+		if match[i] != nil {
+			continue
+		}
+		for _, a := range active {
+			if !matched[a] && normalizeName(a.Name) == normalizeName(item.Name) {
+				match[i], matched[a] = a, true
+				break
+			}
+		}
+	}
+
+	plan := &Plan{}
+	if opt.DeactivateUnlisted {
+		for _, a := range active {
+			if !matched[a] {
+				plan.Changes = append(plan.Changes, Change{Action: Deactivate, Article: a})
+			}
+		}
+	}
+	for i := range items {
+		if a := match[i]; a != nil {
+			if fields := diffFields(a, &items[i]); fields != nil {
+				plan.Changes = append(plan.Changes, Change{Action: Update, Item: &items[i], Article: a, Fields: fields})
+			} else {
+				plan.Unchanged++
+			}
+		}
+	}
+	for i := range items {
+		if match[i] == nil {
+			plan.Changes = append(plan.Changes, Change{Action: Create, Item: &items[i]})
+		}
+	}
+	return plan
+}
+
+func diffFields(a *schema.Article, item *Item) []string {
+	var fields []string
+	if a.Name != item.Name {
+		fields = append(fields, "name")
+	}
+	if schema.Money(a.Value) != item.Value {
+		fields = append(fields, "price")
+	}
+	// another form of the same barcode is no change
+	if item.Barcode != "" && (a.Barcode == nil || !barcode.Equal(*a.Barcode, item.Barcode)) {
+		fields = append(fields, "barcode")
+	}
+	return fields
+}
+
+// Prints the plan as a table, for previewing it.
+func (p *Plan) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "ACTION\tID\tNAME\tPRICE\tBARCODE\n")
+	for _, c := range p.Changes {
+		id, name, price, code := "-", "", "", ""
+		if c.Article != nil {
+			id = fmt.Sprint(c.Article.ID)
+			name, price = c.Article.Name, schema.Money(c.Article.Value).String()
+			if c.Article.Barcode != nil {
+				code = *c.Article.Barcode
+			}
+		}
+		if c.Item != nil {
+			name = changed(name, c.Item.Name)
+			price = changed(price, c.Item.Value.String())
+			if c.Item.Barcode != "" && !barcode.Equal(code, c.Item.Barcode) {
+				code = changed(code, c.Item.Barcode)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Action, id, name, price, code)
+	}
+	fmt.Fprintf(tw, "\n%s, %d unchanged\n", p.summary(), p.Unchanged)
+	return tw.Flush()
+}
+
+func changed(old, new string) string {
+	if old == "" || old == new {
+		return new
+	}
+	return old + " -> " + new
+}
+
+func (p *Plan) summary() string {
+	counts := make(map[Action]int)
+	for _, c := range p.Changes {
+		counts[c.Action]++
+	}
+	var parts []string
+	for _, a := range []Action{Create, Update, Deactivate} {
+		parts = append(parts, fmt.Sprintf("%d to %s", counts[a], a))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// POST /article
+// POST /article/{articleId}
+// DELETE /article/{articleId}
+//
+// Applies the changes in plan order, so that barcodes of deactivated
+// articles are free to be reused. Failed changes don't stop the
+// remaining ones. Returns one result per change, in plan order.
+func (p *Plan) Apply(ctx context.Context) []Result {
+	results := make([]Result, len(p.Changes))
+	for i, c := range p.Changes {
+		results[i] = Result{Change: c}
+		if p.client == nil {
+			results[i].Err = fmt.Errorf("plan has no client; see Diff")
+			continue
+		}
+		results[i].Article, results[i].Err = p.apply(ctx, &c)
+	}
+	return results
+}
+
+func (p *Plan) apply(ctx context.Context, c *Change) (*schema.Article, error) {
+	var a *schema.Article
+	var err error
+
+	switch c.Action {
+	case Create:
+		a, _, err = p.client.Article.Create(ctx, &schema.ArticleCreateRequest{
+			Name:    c.Item.Name,
+			Value:   int(c.Item.Value),
+			Barcode: c.Item.Barcode,
+		})
+	case Update:
+		// keep the article's barcode unless it denotes another product
+		code := c.Item.Barcode
+		if c.Article.Barcode != nil && (code == "" || barcode.Equal(code, *c.Article.Barcode)) {
+			code = *c.Article.Barcode
+		}
+		a, _, err = p.client.Article.Update(ctx, c.Article.ID, &schema.ArticleUpdateRequest{
+			Name:    c.Item.Name,
+			Value:   int(c.Item.Value),
+			Barcode: code,
+		})
+	case Deactivate:
+		a, _, err = p.client.Article.Deactivate(ctx, c.Article.ID)
+	default:
+		err = fmt.Errorf("unknown action %s", c.Action)
+	}
+	return a, err
+}
+
+// Reports whether an update replaced the article with a new version
+// rather than changing it in place.
+func (r *Result) Replaced() bool {
+	return r.Err == nil && r.Change.Action == Update && r.Article != nil && r.Article.ID != r.Change.Article.ID
+}
@@ -0,0 +1,241 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"github.com/jktr/go-strichliste/strichlistetest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func article(id int, name string, value int, barcode string, active bool) schema.Article {
+	a := schema.Article{ID: id, Name: name, Value: value, IsActive: active}
+	if barcode != "" {
+		a.Barcode = &barcode
+	}
+	return a
+}
+
+// Summarizes a plan as "action id name [fields]" lines.
+func changes(p *Plan) []string {
+	var s []string
+	for _, c := range p.Changes {
+		id, name := 0, ""
+		if c.Article != nil {
+			id, name = c.Article.ID, c.Article.Name
+		}
+		if c.Item != nil {
+			name = c.Item.Name
+		}
+		s = append(s, strings.TrimSpace(fmt.Sprintf("%s %d %s %s", c.Action, id, name, strings.Join(c.Fields, ","))))
+	}
+	return s
+}
+
+func TestDiffArticles(t *testing.T) {
+	articles := []schema.Article{
+		article(1, "Club Mate", 150, "4029764001807", true),
+		article(2, "Coffee", 80, "", true),
+		article(3, "Tea", 50, "0036000291452", true),
+		article(4, "Cola", 120, "", false),
+	}
+
+	tests := []struct {
+		name      string
+		items     []Item
+		opt       *Options
+		want      []string
+		unchanged int
+	}{
+		{
+			name: "unchanged",
+			items: []Item{
+				{Name: "Club Mate", Value: 150, Barcode: "4029764001807"},
+				{Name: "Coffee", Value: 80},
+				{Name: "Tea", Value: 50},
+			},
+			unchanged: 3,
+		},
+		{
+			name: "unlisted articles are kept by default",
+			items: []Item{
+				{Name: "Coffee", Value: 80},
+			},
+			unchanged: 1,
+		},
+		{
+			name: "unlisted articles are deactivated on request",
+			items: []Item{
+				{Name: "Coffee", Value: 80},
+			},
+			opt:       &Options{DeactivateUnlisted: true},
+			want:      []string{"deactivate 1 Club Mate", "deactivate 3 Tea"},
+			unchanged: 1,
+		},
+		{
+			name: "matched by barcode despite renaming",
+			items: []Item{
+				{Name: "Mate", Value: 160, Barcode: "4029764001807"},
+			},
+			want: []string{"update 1 Mate name,price"},
+		},
+		{
+			name: "matched by name ignoring case",
+			items: []Item{
+				{Name: "coffee", Value: 80, Barcode: "96385074"},
+			},
+			want: []string{"update 2 coffee name,barcode"},
+		},
+		{
+			name: "matched by another form of the barcode",
+			items: []Item{
+				{Name: "Black Tea", Value: 50, Barcode: "036000291452"},
+			},
+			want: []string{"update 3 Black Tea name"},
+		},
+		{
+			name: "free items",
+			items: []Item{
+				{Name: "Coffee", Value: 0},
+			},
+			want: []string{"update 2 Coffee price"},
+		},
+		{
+			name: "empty barcode keeps the existing one",
+			items: []Item{
+				{Name: "Club Mate", Value: 150},
+			},
+			unchanged: 1,
+		},
+		{
+			name: "inactive articles are not reactivated",
+			items: []Item{
+				{Name: "Cola", Value: 120},
+			},
+			want: []string{"create 0 Cola"},
+		},
+		{
+			name: "deactivations come first and creations last",
+			items: []Item{
+				{Name: "Water", Value: 60},
+				{Name: "Tea", Value: 70},
+			},
+			opt:  &Options{DeactivateUnlisted: true},
+			want: []string{"deactivate 1 Club Mate", "deactivate 2 Coffee", "update 3 Tea price", "create 0 Water"},
+		},
+	}
+	for _, tt := range tests {
+		p := DiffArticles(articles, tt.items, tt.opt)
+		if got := changes(p); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: changes = %q, want %q", tt.name, got, tt.want)
+		}
+		if p.Unchanged != tt.unchanged {
+			t.Errorf("%s: unchanged = %d, want %d", tt.name, p.Unchanged, tt.unchanged)
+		}
+	}
+}
+
+func TestDiffInvalidItems(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Item
+	}{
+		{"missing name", []Item{{Name: " ", Value: 100}}},
+		{"negative price", []Item{{Name: "Water", Value: -10}}},
+		{"duplicate name", []Item{{Name: "Tea", Value: 50}, {Name: "tea", Value: 60}}},
+		{"duplicate barcode", []Item{{Name: "A", Value: 50, Barcode: "1"}, {Name: "B", Value: 60, Barcode: "1"}}},
+		{"duplicate barcode forms", []Item{{Name: "A", Value: 50, Barcode: "036000291452"}, {Name: "B", Value: 60, Barcode: "0036000291452"}}},
+	}
+
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+	client := srv.NewClient()
+
+	for _, tt := range tests {
+		if _, err := Diff(context.Background(), client, tt.items, nil); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+	client := srv.NewClient()
+
+	mate := srv.AddArticle("Club Mate", 150, "4029764001807")
+	coffee := srv.AddArticle("Coffee", 80, "0036000291452")
+	tea := srv.AddArticle("Tea", 50, "")
+
+	// purchased articles are replaced by a new version when updated
+	alice := srv.AddUser("alice", 1000)
+	if _, _, err := client.Transaction.Context(alice.ID).Purchase(ctx, mate.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []Item{
+		{Name: "Club Mate", Value: 170, Barcode: "4029764001807"},
+		{Name: "Coffee", Value: 90, Barcode: "036000291452"}, // UPC-A form
+		{Name: "Water", Value: 0, Barcode: "96385074"},
+	}
+	plan, err := Diff(ctx, client, items, &Options{DeactivateUnlisted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := plan.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "1 to create, 2 to update, 1 to deactivate") {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+
+	results := plan.Apply(ctx)
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Change.Action, r.Err)
+		}
+	}
+
+	replaced := map[int]bool{}
+	for _, r := range results {
+		if r.Change.Action == Update {
+			replaced[r.Change.Article.ID] = r.Replaced()
+		}
+	}
+	if !replaced[mate.ID] || replaced[coffee.ID] {
+		t.Errorf("replaced = %v, want only article %d replaced", replaced, mate.ID)
+	}
+
+	if a, _, err := client.Article.Get(ctx, coffee.ID); err != nil || a.Barcode == nil || *a.Barcode != "0036000291452" {
+		t.Errorf("coffee: barcode not kept: %+v (err %v)", a, err)
+	}
+	if a, _, err := client.Article.Get(ctx, tea.ID); err != nil || a.IsActive {
+		t.Errorf("tea: active after deactivation (err %v)", err)
+	}
+
+	// applying the same items again changes nothing
+	plan, err = Diff(ctx, client, items, &Options{DeactivateUnlisted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 0 || plan.Unchanged != 3 {
+		t.Errorf("second plan: %q, %d unchanged", changes(plan), plan.Unchanged)
+	}
+}
+
+func TestApplyWithoutClient(t *testing.T) {
+	plan := DiffArticles(nil, []Item{{Name: "Water", Value: 60}}, nil)
+	results := plan.Apply(context.Background())
+	if len(results) != 1 || results[0].Err == nil {
+		t.Errorf("results = %+v, want an error", results)
+	}
+}
@@ -0,0 +1,112 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Reads items from a YAML list. Prices are in the currency's major
+// unit and parsed with schema.ParseMoney, so both 1.5 and "1,50 €"
+// mean 150 cents:
+//
+//	# drinks.yaml
+//	- name: Club Mate
+//	  price: 1.50
+//	  barcode: "4029764001807"
+//	- name: Coffee
+//	  price: 0.80
+func ReadYAML(r io.Reader) ([]Item, error) {
+	var raw []struct {
+		Name    string    `yaml:"name"`
+		Price   yaml.Node `yaml:"price"`
+		Barcode yaml.Node `yaml:"barcode"`
+	}
+	if err := yaml.NewDecoder(r).Decode(&raw); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+
+	items := make([]Item, 0, len(raw))
+	for i, r := range raw {
+		if r.Price.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("item %d (%s): missing price", i+1, r.Name)
+		}
+		value, err := schema.ParseMoney(r.Price.Value)
+		if err != nil {
+			return nil, fmt.Errorf("item %d (%s): %w", i+1, r.Name, err)
+		}
+		// barcodes are kept verbatim, even if written as numbers
+		items = append(items, Item{Name: strings.TrimSpace(r.Name), Value: value, Barcode: strings.TrimSpace(r.Barcode.Value)})
+	}
+
+	if err := validate(items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Reads items from CSV, e.g. as exported from a spreadsheet. The first
+// record is a header naming the columns "name", "price", and optionally
+// "barcode", in any order and case; other columns are ignored. Both
+// commas and semicolons are accepted as separators. Prices are parsed
+// as with ReadYAML.
+func ReadCSV(r io.Reader) ([]Item, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(strings.NewReader(string(data)))
+	cr.TrimLeadingSpace = true
+	if first := strings.SplitN(string(data), "\n", 2)[0]; strings.Count(first, ";") > strings.Count(first, ",") {
+		cr.Comma = ';'
+	}
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("invalid catalog: missing header")
+	}
+
+	columns := map[string]int{"name": -1, "price": -1, "barcode": -1}
+	for i, h := range records[0] {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, ok := columns[h]; ok {
+			columns[h] = i
+		}
+	}
+	if columns["name"] < 0 || columns["price"] < 0 {
+		return nil, fmt.Errorf("invalid catalog: header lacks name or price column")
+	}
+
+	field := func(record []string, column string) string {
+		if i := columns[column]; i >= 0 && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	items := make([]Item, 0, len(records)-1)
+	for i, record := range records[1:] {
+		name, price := field(record, "name"), field(record, "price")
+		if name == "" && price == "" {
+			continue // blank spreadsheet rows
+		}
+		value, err := schema.ParseMoney(price)
+		if err != nil {
+			return nil, fmt.Errorf("line %d (%s): %w", i+2, name, err)
+		}
+		items = append(items, Item{Name: name, Value: value, Barcode: field(record, "barcode")})
+	}
+
+	if err := validate(items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,71 @@
+package catalog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Item
+		ok   bool
+	}{
+		{
+			name: "prices as numbers and strings",
+			in:   "- name: Club Mate\n  price: 1.50\n  barcode: 4029764001807\n- name: Coffee\n  price: \"0,80 €\"\n",
+			want: []Item{{Name: "Club Mate", Value: 150, Barcode: "4029764001807"}, {Name: "Coffee", Value: 80}},
+			ok:   true,
+		},
+		{name: "empty", in: "", want: []Item{}, ok: true},
+		{name: "missing price", in: "- name: Tea\n", ok: false},
+		{name: "ambiguous price", in: "- name: Tea\n  price: 1.500\n", ok: false},
+		{name: "duplicate name", in: "- name: Tea\n  price: 1\n- name: tea\n  price: 2\n", ok: false},
+	}
+	for _, tt := range tests {
+		got, err := ReadYAML(strings.NewReader(tt.in))
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok = %v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Item
+		ok   bool
+	}{
+		{
+			name: "commas",
+			in:   "name,price,barcode\nClub Mate,1.50,4029764001807\nCoffee,0.8,\n",
+			want: []Item{{Name: "Club Mate", Value: 150, Barcode: "4029764001807"}, {Name: "Coffee", Value: 80}},
+			ok:   true,
+		},
+		{
+			name: "semicolons, other column order, and a byte order mark",
+			in:   "\ufeffPrice;Name;Stock\n1,50;Club Mate;12\n",
+			want: []Item{{Name: "Club Mate", Value: 150}},
+			ok:   true,
+		},
+		{name: "missing column", in: "name,barcode\nTea,1\n", ok: false},
+		{name: "invalid price", in: "name,price\nTea,free\n", ok: false},
+	}
+	for _, tt := range tests {
+		got, err := ReadCSV(strings.NewReader(tt.in))
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok = %v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
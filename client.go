@@ -2,13 +2,17 @@ package strichliste
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/jktr/go-strichliste/schema"
+	"html"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -27,10 +31,20 @@ type (
 	Client struct {
 		endpoint   string
 		httpClient *http.Client
+		transport  http.RoundTripper // applied to httpClient; nil keeps its own
 		appName    string
 		appVersion string
 		userAgent  string // derived via appName/appVersion
 
+		retryPolicy    *RetryPolicy // nil means no retries
+		middleware     []Middleware
+		credentials    Credentials
+		roundTrip      RoundTripFunc // derived via httpClient/middleware
+		idempotency    idempotencyTracker
+		settingsCache  settingsCache
+		articleCache   articleCache
+		strictBarcodes bool
+
 		User        UserClient
 		Transaction TransactionClient
 		Article     ArticleClient
@@ -41,7 +55,8 @@ type (
 	// Allows for result pagination and result limitation.
 	//
 	// Pagination works by passing a page number and the number of
-	// items on each page.
+	// items on each page. Pages are requested by offset, which the
+	// server honors, as well as by page number.
 	//
 	// Limiting results works by keeping Page at 0 and setting
 	// PerPage to the desired number of total entries.
@@ -65,6 +80,9 @@ func (l *ListOpts) values() url.Values {
 
 	if l.Page > 0 {
 		v.Add("page", strconv.Itoa(int(l.Page)))
+		if l.PerPage > 0 {
+			v.Add("offset", strconv.Itoa(int((l.Page-1)*l.PerPage)))
+		}
 	}
 	if l.PerPage > 0 {
 		v.Add("limit", strconv.Itoa(int(l.PerPage)))
@@ -91,6 +109,29 @@ func WithApplication(name, version string) ClientOption {
 	}
 }
 
+// Configure the http.Client used for contacting the API endpoint,
+// e.g. for setting timeouts, proxies, or TLS settings.
+// Not setting this option, or passing nil, will default to a zero
+// http.Client.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(client *Client) {
+		if httpClient == nil {
+			httpClient = &http.Client{}
+		}
+		client.httpClient = httpClient
+	}
+}
+
+// Configure the http.RoundTripper used for contacting the API
+// endpoint. Applies to the http.Client set via WithHTTPClient, if any,
+// regardless of the order of options, without modifying it.
+// Passing nil keeps the http.Client's own transport.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(client *Client) {
+		client.transport = transport
+	}
+}
+
 // Create a new API client. This is the library's entrypoint.
 func NewClient(options ...ClientOption) *Client {
 	client := &Client{
@@ -99,12 +140,20 @@ func NewClient(options ...ClientOption) *Client {
 		appName:    LibName,
 		appVersion: fmt.Sprintf("%s (api:%s)", LibVersion, ApiVersion),
 	}
+	client.settingsCache.ttl = DefaultSettingsTTL
 
 	for _, option := range options {
 		option(client)
 	}
 
+	if client.transport != nil {
+		hc := *client.httpClient
+		hc.Transport = client.transport
+		client.httpClient = &hc
+	}
+
 	client.userAgent = client.appName + "/" + client.appVersion
+	client.roundTrip = chain(authorize(client.httpClient.Do, client.credentials), client.middleware)
 
 	client.Article = ArticleClient{client: client}
 	client.User = UserClient{client: client}
@@ -129,7 +178,8 @@ func newJsonReader(obj interface{}) (io.Reader, error) {
 // Builds a http.Request suitable for contacting the API endpoint.
 // Method should be GET/POST/DELETE/etc.
 // Body may be any one of the …Request strichliste.schema structs.
-func (c *Client) NewRequest(method, path string, body interface{}) (*http.Request, error) {
+// Cancellation and deadlines of ctx propagate to the HTTP layer.
+func (c *Client) NewRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
 	reader, err := newJsonReader(body)
 	if err != nil {
 		return nil, err
@@ -139,6 +189,7 @@ func (c *Client) NewRequest(method, path string, body interface{}) (*http.Reques
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
@@ -155,10 +206,42 @@ type Response struct {
 // Executes an API call. Parses the response and any errors.
 // A suitable reqest may be prepared via NewRequest.
 // Obj may be any one of the …Single-/MultiResponse strichliste.schema structs.
+// The request is bound to the context passed to NewRequest.
+// Failed attempts are retried according to the client's RetryPolicy.
 func (c *Client) Do(req *http.Request, obj interface{}) (*Response, error) {
-	resp, err := c.httpClient.Do(req)
+	for attempt := 1; ; attempt++ {
+		response, body, err := c.do(req)
+		if err != nil {
+			delay, retry := c.retryPolicy.backoff(c.retryAttempt(req, response, attempt, err))
+			if !retry {
+				return response, err
+			}
+			if err := sleep(req.Context(), delay); err != nil {
+				return response, err
+			}
+			if err := rewind(req); err != nil {
+				return response, err
+			}
+			continue
+		}
+
+		if obj != nil {
+			if w, ok := obj.(io.Writer); ok {
+				_, err = io.Copy(w, bytes.NewReader(body))
+			} else {
+				err = json.Unmarshal(body, obj)
+			}
+		}
+		return response, err
+	}
+}
+
+// Performs a single attempt of an API call. Returns the response,
+// its body, and any transport error or *Error.
+func (c *Client) do(req *http.Request) (*Response, []byte, error) {
+	resp, err := c.roundTrip(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, redactError(err)
 	}
 	defer resp.Body.Close()
 
@@ -167,39 +250,42 @@ func (c *Client) Do(req *http.Request, obj interface{}) (*Response, error) {
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		resp.Body.Close()
-		return response, err
+		return response, nil, err
 	}
 	resp.Body.Close()
 	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
 
 	if resp.StatusCode >= 400 && resp.StatusCode <= 599 {
-		err = errorFromResponse(resp, body)
-		if err == nil {
-			err = fmt.Errorf("%s: server responded with status code %d",
-				c.appName, resp.StatusCode)
+		e := &Error{
+			Method:     req.Method,
+			Path:       c.apiPath(req.URL),
+			StatusCode: resp.StatusCode,
+			Body:       body,
+			Err:        ErrUnexpectedStatus,
 		}
-		return response, err
-	}
-
-	if obj != nil {
-		if w, ok := obj.(io.Writer); ok {
-			_, err = io.Copy(w, bytes.NewReader(body))
+		if er := errorFromResponse(resp, body); er != nil {
+			e.Err = er
 		} else {
-			err = json.Unmarshal(body, obj)
+			e.Message = describeErrorPage(resp, body)
 		}
+		return response, body, e
 	}
-	return response, err
+
+	return response, body, nil
 }
 
-func errorFromResponse(resp *http.Response, body []byte) error {
-	if resp.Header.Get("Content-Type") != "application/json" {
+// Extracts an API error from an error response. The body is parsed if
+// it is declared as JSON, regardless of any media type parameters, or
+// if it looks like JSON despite a missing or generic Content-Type.
+func errorFromResponse(resp *http.Response, body []byte) *schema.ErrorResponse {
+	if !isJSON(resp.Header.Get("Content-Type"), body) {
 		return nil
 	}
 	var er schema.SingleErrorResponse
 	if err := json.Unmarshal(body, &er); err != nil {
 		return nil
 	}
-	if er.Error.Class == "" { //|| er.Error.Code == 0 {
+	if er.Error.Class == "" {
 		return nil
 	}
 	if er.Error.Message == "" {
@@ -207,3 +293,43 @@ func errorFromResponse(resp *http.Response, body []byte) error {
 	}
 	return &er.Error
 }
+
+func isJSON(contentType string, body []byte) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		switch {
+		case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+			return true
+		case mediaType != "text/plain" && mediaType != "application/octet-stream":
+			return false
+		}
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+// Describes a non-API error response, like an HTML error page of a
+// reverse proxy, via its title. Returns "" if there's nothing useful.
+func describeErrorPage(resp *http.Response, body []byte) string {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		m := htmlTitle.FindSubmatch(body)
+		if m == nil {
+			return ""
+		}
+		return strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+	case "text/plain":
+		s := strings.TrimSpace(string(body))
+		if len(s) > 200 || strings.ContainsAny(s, "\n\r") {
+			return ""
+		}
+		return s
+	}
+	return ""
+}
+
+var htmlTitle = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
@@ -0,0 +1,45 @@
+package strichliste
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+type nopTransport struct{}
+
+func (nopTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, http.ErrNotSupported
+}
+
+func TestClientOptions(t *testing.T) {
+	hc := &http.Client{Timeout: time.Minute}
+	tr := nopTransport{}
+
+	orders := map[string][]ClientOption{
+		"client first":    {WithHTTPClient(hc), WithTransport(tr)},
+		"transport first": {WithTransport(tr), WithHTTPClient(hc)},
+	}
+	for name, options := range orders {
+		c := NewClient(options...)
+		if c.httpClient.Transport != tr {
+			t.Errorf("%s: transport not applied", name)
+		}
+		if c.httpClient.Timeout != time.Minute {
+			t.Errorf("%s: http.Client settings lost", name)
+		}
+	}
+	if hc.Transport != nil {
+		t.Error("passed http.Client was modified")
+	}
+
+	c := NewClient(WithHTTPClient(nil))
+	if c.httpClient == nil {
+		t.Error("nil http.Client kept")
+	}
+
+	c = NewClient(WithHTTPClient(hc), WithTransport(nil))
+	if c.httpClient != hc {
+		t.Error("nil transport replaced the http.Client")
+	}
+}
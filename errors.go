@@ -0,0 +1,133 @@
+package strichliste
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Wrapped by an Error if the server responded with an error status,
+// but didn't provide an API error.
+var ErrUnexpectedStatus = errors.New("server responded with unexpected status code")
+
+// An Error describes an API call that received an error response.
+//
+// Transport errors, like failing to connect to the server, are
+// returned as-is and are never an Error.
+type Error struct {
+	Method     string // GET/POST/DELETE/etc.
+	Path       string // API path, e.g. "/user/42"
+	StatusCode int
+	Body       []byte // raw response body
+
+	// Describes the error if the server didn't provide an API error,
+	// e.g. the title of a reverse proxy's error page. May be empty.
+	Message string
+
+	// The underlying error; either a *schema.ErrorResponse, or
+	// ErrUnexpectedStatus.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != ErrUnexpectedStatus {
+		return fmt.Sprintf("%s %s: %s", e.Method, e.Path, e.Err)
+	}
+	if e.Message != "" {
+		return fmt.Sprintf("%s %s: server responded with status code %d: %s",
+			e.Method, e.Path, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s %s: server responded with status code %d",
+		e.Method, e.Path, e.StatusCode)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Returns the class of the API error, if any.
+func (e *Error) Class() schema.ErrorClass {
+	var er *schema.ErrorResponse
+	if errors.As(e.Err, &er) {
+		return er.Class
+	}
+	return ""
+}
+
+// Returns the code the server reported alongside the API error, if any.
+// Note that this usually differs from StatusCode; see schema.ErrorResponse.
+func (e *Error) Code() int {
+	var er *schema.ErrorResponse
+	if errors.As(e.Err, &er) {
+		return er.Code
+	}
+	return 0
+}
+
+// Returns the class of the API error that caused err, if any.
+func ErrorClassOf(err error) schema.ErrorClass {
+	var er *schema.ErrorResponse
+	if errors.As(err, &er) {
+		return er.Class
+	}
+	return ""
+}
+
+// Reports whether err was caused by a user, article, or transaction
+// that doesn't exist. A missing request parameter is a client error
+// and doesn't count, even if the server responds with 404.
+func IsNotFound(err error) bool {
+	switch ErrorClassOf(err) {
+	case schema.ErrorUserNotFound, schema.ErrorArticleNotFound,
+		schema.ErrorTransactionNotFound:
+		return true
+	case schema.ErrorParameterNotFound:
+		return false
+	}
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// Reports whether err was caused by a transaction that would exceed
+// either the account balance or the transaction amount boundaries.
+func IsBoundaryViolation(err error) bool {
+	switch ErrorClassOf(err) {
+	case schema.ErrorAccountBalanceBoundary, schema.ErrorTransactionBoundary:
+		return true
+	}
+	return false
+}
+
+// Reports whether err is likely to go away on its own, i.e. whether
+// repeating the call at a later time may succeed. This is the case for
+// transport errors and server errors without an API error class, but
+// not for cancellation or deadlines of the caller's context.
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Class() != "" {
+			return false
+		}
+		switch e.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF)
+}
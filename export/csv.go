@@ -0,0 +1,94 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A CSVEncoder writes transactions as CSV records, after a header
+// record with the column names. Amounts are written in the currency's
+// major unit, like "-1.50".
+type CSVEncoder struct {
+	w   *csv.Writer
+	opt Options
+}
+
+// Creates a CSV encoder and writes the header. Options can be nil.
+func NewCSVEncoder(w io.Writer, opt *Options) (*CSVEncoder, error) {
+	e := &CSVEncoder{w: csv.NewWriter(w)}
+	if opt != nil {
+		e.opt = *opt
+	}
+	if err := e.opt.check(); err != nil {
+		return nil, err
+	}
+	if e.opt.Comma != 0 {
+		e.w.Comma = e.opt.Comma
+	}
+	if e.opt.TimeLayout == "" {
+		e.opt.TimeLayout = schema.TimestampLayout
+	}
+
+	columns := e.opt.columns()
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = string(c)
+	}
+	return e, e.w.Write(header)
+}
+
+func (e *CSVEncoder) Encode(tx *schema.Transaction) error {
+	columns := e.opt.columns()
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = e.format(value(c, tx, e.opt.location()))
+	}
+	return e.w.Write(record)
+}
+
+func (e *CSVEncoder) format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(e.opt.TimeLayout)
+	case schema.Money:
+		if e.opt.DecimalComma {
+			return strings.Replace(v.String(), ".", ",", 1)
+		}
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func (e *CSVEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// GET /settings
+// GET /transaction
+// GET /user/{userId}/transaction
+//
+// Streams transactions as CSV, most recent first; see CSVEncoder.
+// Options can be nil. Returns the number of exported transactions.
+func CSV(ctx context.Context, client *strichliste.Client, w io.Writer, opt *Options) (int, error) {
+	opt, err := resolve(ctx, client, opt)
+	if err != nil {
+		return 0, err
+	}
+	enc, err := NewCSVEncoder(w, opt)
+	if err != nil {
+		return 0, err
+	}
+	return Export(ctx, client, enc, opt)
+}
@@ -0,0 +1,228 @@
+// Package export streams transactions into formats for bookkeeping
+// and spreadsheets: CSV, JSON Lines, and the plain-text accounting
+// formats of ledger, hledger, and beancount.
+//
+//	n, err := export.CSV(ctx, client, os.Stdout, &export.Options{
+//		Columns: []export.Column{export.ColumnTime, export.ColumnUser, export.ColumnAmount},
+//	})
+package export
+
+import (
+	"context"
+	"fmt"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"strings"
+	"time"
+)
+
+// A Column is a field of an exported transaction.
+type Column string
+
+const (
+	ColumnID          Column = "id"
+	ColumnTime        Column = "time" // in Options.Location
+	ColumnUserID      Column = "user_id"
+	ColumnUser        Column = "user" // the user's name
+	ColumnAmount      Column = "amount"
+	ColumnComment     Column = "comment"
+	ColumnArticleID   Column = "article_id"
+	ColumnArticle     Column = "article" // the article's name
+	ColumnQuantity    Column = "quantity"
+	ColumnSenderID    Column = "sender_id"
+	ColumnSender      Column = "sender"
+	ColumnRecipientID Column = "recipient_id"
+	ColumnRecipient   Column = "recipient"
+	ColumnReversed    Column = "reversed"
+)
+
+// The columns that are exported unless configured otherwise.
+var DefaultColumns = []Column{
+	ColumnID, ColumnTime, ColumnUser, ColumnAmount, ColumnComment,
+	ColumnArticle, ColumnQuantity, ColumnSender, ColumnRecipient, ColumnReversed,
+}
+
+var allColumns = []Column{
+	ColumnID, ColumnTime, ColumnUserID, ColumnUser, ColumnAmount, ColumnComment,
+	ColumnArticleID, ColumnArticle, ColumnQuantity, ColumnSenderID, ColumnSender,
+	ColumnRecipientID, ColumnRecipient, ColumnReversed,
+}
+
+// Parses a comma-separated list of column names, like "id,time,amount".
+func ParseColumns(s string) ([]Column, error) {
+	var columns []Column
+	for _, name := range strings.Split(s, ",") {
+		c := Column(strings.TrimSpace(name))
+		if !c.valid() {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+func (c Column) valid() bool {
+	for _, k := range allColumns {
+		if c == k {
+			return true
+		}
+	}
+	return false
+}
+
+type (
+	// Options for exporting transactions.
+	Options struct {
+		// Export only this user's transactions; zero means all users.
+		User int
+		// The columns to export, in order; nil means DefaultColumns.
+		Columns []Column
+		// The timezone of exported times; nil means the server's, as
+		// per Settings.I18n.Timezone; see schema.Settings.Location.
+		// Encoders created directly default to UTC instead.
+		Location *time.Location
+		// Limits the export; nil means all transactions.
+		Iter *strichliste.IterOpts
+
+		// CSV only: the layout of times; empty means
+		// schema.TimestampLayout.
+		TimeLayout string
+		// CSV only: the field separator; zero means ','. Many
+		// spreadsheets in decimal comma locales expect ';'.
+		Comma rune
+		// CSV only: write amounts with a decimal comma, like "-1,50".
+		DecimalComma bool
+	}
+
+	// An Encoder writes transactions in some format.
+	Encoder interface {
+		Encode(tx *schema.Transaction) error
+		// Writes any buffered data.
+		Flush() error
+	}
+)
+
+func (o *Options) columns() []Column {
+	if o.Columns == nil {
+		return DefaultColumns
+	}
+	return o.Columns
+}
+
+func (o *Options) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+func (o *Options) check() error {
+	for _, c := range o.columns() {
+		if !c.valid() {
+			return fmt.Errorf("unknown column %q", c)
+		}
+	}
+	return nil
+}
+
+// Returns a copy of the options with the location defaulted to the
+// server's timezone. Options can be nil.
+func resolve(ctx context.Context, client *strichliste.Client, opt *Options) (*Options, error) {
+	o := Options{}
+	if opt != nil {
+		o = *opt
+	}
+	if err := o.check(); err != nil {
+		return nil, err
+	}
+	if o.Location == nil {
+		settings, err := client.Settings.Cached(ctx)
+		if err != nil {
+			return nil, err
+		}
+		o.Location = settings.Location()
+	}
+	return &o, nil
+}
+
+// GET /transaction
+// GET /user/{userId}/transaction
+//
+// Streams transactions into an encoder, most recent first, and flushes
+// it. Only Options.User and Options.Iter are considered; options can
+// be nil. Returns the number of exported transactions.
+func Export(ctx context.Context, client *strichliste.Client, enc Encoder, opt *Options) (int, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+
+	var it *strichliste.TransactionIterator
+	if opt.User != 0 {
+		it = client.Transaction.Context(opt.User).ListIter(opt.Iter)
+	} else {
+		it = client.Transaction.ListIter(opt.Iter)
+	}
+
+	n := 0
+	for it.Next(ctx) {
+		tx := it.Transaction()
+		if err := enc.Encode(&tx); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := it.Err(); err != nil {
+		enc.Flush()
+		return n, err
+	}
+	return n, enc.Flush()
+}
+
+// Returns the value of a column; nil if the transaction lacks it.
+func value(c Column, tx *schema.Transaction, loc *time.Location) interface{} {
+	switch c {
+	case ColumnID:
+		return tx.ID
+	case ColumnTime:
+		return tx.TimeCreated.In(loc)
+	case ColumnUserID:
+		return tx.Issuer.ID
+	case ColumnUser:
+		return tx.Issuer.Name
+	case ColumnAmount:
+		return schema.Money(tx.Value)
+	case ColumnComment:
+		return tx.Comment
+	case ColumnArticleID:
+		if tx.Article != nil {
+			return tx.Article.ID
+		}
+	case ColumnArticle:
+		if tx.Article != nil {
+			return tx.Article.Name
+		}
+	case ColumnQuantity:
+		if tx.Quantity != nil {
+			return *tx.Quantity
+		}
+	case ColumnSenderID:
+		if tx.From != nil {
+			return tx.From.ID
+		}
+	case ColumnSender:
+		if tx.From != nil {
+			return tx.From.Name
+		}
+	case ColumnRecipientID:
+		if tx.To != nil {
+			return tx.To.ID
+		}
+	case ColumnRecipient:
+		if tx.To != nil {
+			return tx.To.Name
+		}
+	case ColumnReversed:
+		return tx.IsReversed
+	}
+	return nil
+}
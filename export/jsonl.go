@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"time"
+)
+
+// A JSONLinesEncoder writes transactions as JSON objects, one per line,
+// keyed by column name. Amounts are integers in the currency's minor
+// unit, like -150, times are RFC 3339 strings, and missing values are
+// null.
+type JSONLinesEncoder struct {
+	w   *bufio.Writer
+	opt Options
+}
+
+// Creates a JSON Lines encoder. Options can be nil.
+func NewJSONLinesEncoder(w io.Writer, opt *Options) (*JSONLinesEncoder, error) {
+	e := &JSONLinesEncoder{w: bufio.NewWriter(w)}
+	if opt != nil {
+		e.opt = *opt
+	}
+	if err := e.opt.check(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *JSONLinesEncoder) Encode(tx *schema.Transaction) error {
+	// build the object by hand to keep the column order
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, c := range e.opt.columns() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(string(c))
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		v := value(c, tx, e.opt.location())
+		if t, ok := v.(time.Time); ok {
+			v = t.Format(time.RFC3339)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	buf.WriteString("}\n")
+
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+func (e *JSONLinesEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+// GET /settings
+// GET /transaction
+// GET /user/{userId}/transaction
+//
+// Streams transactions as JSON Lines, most recent first; see
+// JSONLinesEncoder. Options can be nil. Returns the number of exported
+// transactions.
+func JSONLines(ctx context.Context, client *strichliste.Client, w io.Writer, opt *Options) (int, error) {
+	opt, err := resolve(ctx, client, opt)
+	if err != nil {
+		return 0, err
+	}
+	enc, err := NewJSONLinesEncoder(w, opt)
+	if err != nil {
+		return 0, err
+	}
+	return Export(ctx, client, enc, opt)
+}
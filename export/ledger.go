@@ -0,0 +1,309 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A LedgerFormat is a plain-text accounting syntax.
+type LedgerFormat int
+
+const (
+	// The syntax of ledger, which hledger reads as well.
+	FormatLedger LedgerFormat = iota
+	FormatBeancount
+)
+
+// Placeholders in account templates, replaced by the sanitized names
+// and IDs of the transaction's user or article.
+const (
+	PlaceholderUser      = "{user}"
+	PlaceholderUserID    = "{user_id}"
+	PlaceholderArticle   = "{article}"
+	PlaceholderArticleID = "{article_id}"
+)
+
+// Account templates used unless configured otherwise.
+const (
+	DefaultUserAccount    = "Liabilities:Strichliste:Users:{user}"
+	DefaultCashAccount    = "Assets:Strichliste:Cash"
+	DefaultArticleAccount = "Income:Strichliste:Articles:{article}"
+)
+
+// Options for exporting double-entry postings.
+//
+// User balances are liabilities: money deposited is owed back to the
+// user. So a deposit credits the user's account and debits the cash
+// account, a purchase debits the user's account and credits the
+// article's income account, and a transfer moves the balance between
+// both users' accounts.
+type LedgerOptions struct {
+	Format LedgerFormat
+
+	// Account templates; empty means the respective default.
+	UserAccount    string // the user's balance
+	CashAccount    string // the other side of deposits and withdrawals
+	ArticleAccount string // the other side of purchases
+
+	// The commodity of amounts; empty means the server's currency as
+	// per Settings.I18n.Currency, or "EUR" for encoders created directly.
+	Currency string
+
+	// Reversed transactions are omitted unless this is set, in which
+	// case they are written along with a reversal entry that cancels
+	// them out. The server doesn't report when they were reversed, so
+	// the reversal entry has the date of the transaction.
+	KeepReversed bool
+}
+
+// A LedgerEncoder writes transactions as double-entry accounting
+// entries; see LedgerOptions.
+//
+// Transfers appear in the transactions of both users. When exporting
+// all users' transactions, only the sender's is written. When
+// exporting a single user's, as per Options.User, both are.
+//
+// Beancount requires accounts to be opened before their first use.
+// Since transactions arrive most recent first, the encoder collects
+// the accounts and writes their open directives, dated on their first
+// use, when flushed; beancount doesn't care about the order of
+// directives. So flush only once, after encoding all transactions.
+type LedgerEncoder struct {
+	w    *bufio.Writer
+	opt  Options
+	lopt LedgerOptions
+
+	accounts map[string]string // beancount only: account to date of first use
+	opened   map[string]bool   // beancount only: accounts already opened
+}
+
+// Creates a ledger encoder. Options can be nil.
+func NewLedgerEncoder(w io.Writer, opt *Options, lopt *LedgerOptions) (*LedgerEncoder, error) {
+	e := &LedgerEncoder{
+		w:        bufio.NewWriter(w),
+		accounts: make(map[string]string),
+		opened:   make(map[string]bool),
+	}
+	if opt != nil {
+		e.opt = *opt
+	}
+	if lopt != nil {
+		e.lopt = *lopt
+	}
+
+	switch e.lopt.Format {
+	case FormatLedger, FormatBeancount:
+	default:
+		return nil, fmt.Errorf("unknown ledger format %d", e.lopt.Format)
+	}
+	if e.lopt.UserAccount == "" {
+		e.lopt.UserAccount = DefaultUserAccount
+	}
+	if e.lopt.CashAccount == "" {
+		e.lopt.CashAccount = DefaultCashAccount
+	}
+	if e.lopt.ArticleAccount == "" {
+		e.lopt.ArticleAccount = DefaultArticleAccount
+	}
+	if e.lopt.Currency == "" {
+		e.lopt.Currency = "EUR"
+	}
+	return e, nil
+}
+
+type posting struct {
+	account string
+	amount  schema.Money
+}
+
+func (e *LedgerEncoder) Encode(tx *schema.Transaction) error {
+	if tx.IsReversed && !e.lopt.KeepReversed {
+		return nil
+	}
+	// the sender's side of the transfer covers both accounts
+	if tx.From != nil && e.opt.User == 0 {
+		return nil
+	}
+
+	value := schema.Money(tx.Value)
+	user := e.account(e.lopt.UserAccount, &tx.Issuer, nil)
+
+	var narration, other string
+	switch {
+	case tx.Article != nil:
+		quantity := 1
+		if tx.Quantity != nil {
+			quantity = *tx.Quantity
+		}
+		narration = fmt.Sprintf("%dx %s", quantity, tx.Article.Name)
+		other = e.account(e.lopt.ArticleAccount, nil, tx.Article)
+	case tx.To != nil:
+		narration = "Transfer to " + tx.To.Name
+		other = e.account(e.lopt.UserAccount, tx.To, nil)
+	case tx.From != nil:
+		narration = "Transfer from " + tx.From.Name
+		other = e.account(e.lopt.UserAccount, tx.From, nil)
+	case value > 0:
+		narration = "Deposit"
+		other = e.account(e.lopt.CashAccount, &tx.Issuer, nil)
+	default:
+		narration = "Withdrawal"
+		other = e.account(e.lopt.CashAccount, &tx.Issuer, nil)
+	}
+
+	postings := []posting{{user, value.Neg()}, {other, value}}
+	meta := [][2]string{
+		{"id", strconv.Itoa(tx.ID)},
+		{"time", tx.TimeCreated.In(e.opt.location()).Format("15:04:05")},
+	}
+	if tx.Comment != "" {
+		meta = append(meta, [2]string{"comment", tx.Comment})
+	}
+	e.entry(tx, tx.Issuer.Name, narration, meta, postings)
+
+	if tx.IsReversed {
+		for i := range postings {
+			postings[i].amount = postings[i].amount.Neg()
+		}
+		meta = [][2]string{{"reverses", strconv.Itoa(tx.ID)}}
+		e.entry(tx, tx.Issuer.Name, "Reversal: "+narration, meta, postings)
+	}
+	return nil
+}
+
+func (e *LedgerEncoder) entry(tx *schema.Transaction, payee, narration string, meta [][2]string, postings []posting) {
+	date := tx.TimeCreated.In(e.opt.location()).Format("2006-01-02")
+	w := e.w
+
+	switch e.lopt.Format {
+	case FormatLedger:
+		fmt.Fprintf(w, "%s * %s | %s\n", date, oneLine(payee), oneLine(narration))
+		for _, m := range meta {
+			fmt.Fprintf(w, "    ; %s: %s\n", m[0], oneLine(m[1]))
+		}
+	case FormatBeancount:
+		fmt.Fprintf(w, "%s * %s %s\n", date, quote(payee), quote(narration))
+		for _, m := range meta {
+			fmt.Fprintf(w, "  %s: %s\n", m[0], quote(m[1]))
+		}
+	}
+	for _, p := range postings {
+		fmt.Fprintf(w, "  %-50s  %10s %s\n", p.account, p.amount, e.lopt.Currency)
+		if first, ok := e.accounts[p.account]; e.lopt.Format == FormatBeancount && (!ok || date < first) {
+			e.accounts[p.account] = date
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// Writes the open directives of beancount accounts, if any, and any
+// buffered data.
+func (e *LedgerEncoder) Flush() error {
+	var accounts []string
+	for account := range e.accounts {
+		if !e.opened[account] {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Strings(accounts)
+	for _, account := range accounts {
+		fmt.Fprintf(e.w, "%s open %s %s\n", e.accounts[account], account, e.lopt.Currency)
+		e.opened[account] = true
+	}
+	return e.w.Flush()
+}
+
+// Expands an account template for a user or article.
+func (e *LedgerEncoder) account(template string, u *schema.User, a *schema.Article) string {
+	var pairs []string
+	if u != nil {
+		pairs = append(pairs, PlaceholderUser, e.component(u.Name), PlaceholderUserID, strconv.Itoa(u.ID))
+	}
+	if a != nil {
+		pairs = append(pairs, PlaceholderArticle, e.component(a.Name), PlaceholderArticleID, strconv.Itoa(a.ID))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// Turns a name into a valid account name component. Beancount requires
+// components to start with an uppercase letter or digit, followed by
+// letters, digits, or dashes; ledger only forbids colons and runs of
+// whitespace.
+func (e *LedgerEncoder) component(name string) string {
+	if e.lopt.Format == FormatLedger {
+		name = strings.Join(strings.Fields(strings.Replace(name, ":", "-", -1)), " ")
+		if name == "" {
+			return "Unnamed"
+		}
+		return name
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if b.Len() == 0 {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+			dash = false
+		} else if b.Len() > 0 && !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	s := strings.TrimSuffix(b.String(), "-")
+	if s == "" {
+		return "Unnamed"
+	}
+	return s
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func quote(s string) string {
+	return strconv.Quote(oneLine(s))
+}
+
+// GET /settings
+// GET /transaction
+// GET /user/{userId}/transaction
+//
+// Streams transactions as double-entry accounting entries, most recent
+// first; both ledger and beancount sort entries by date themselves.
+// See LedgerEncoder. Options can be nil.
+// Returns the number of transactions read, including omitted ones.
+func Ledger(ctx context.Context, client *strichliste.Client, w io.Writer, opt *Options, lopt *LedgerOptions) (int, error) {
+	opt, err := resolve(ctx, client, opt)
+	if err != nil {
+		return 0, err
+	}
+
+	l := LedgerOptions{}
+	if lopt != nil {
+		l = *lopt
+	}
+	if l.Currency == "" {
+		settings, err := client.Settings.Cached(ctx)
+		if err != nil {
+			return 0, err
+		}
+		l.Currency = settings.I18n.Currency.Alpha3
+	}
+
+	enc, err := NewLedgerEncoder(w, opt, &l)
+	if err != nil {
+		return 0, err
+	}
+	return Export(ctx, client, enc, opt)
+}
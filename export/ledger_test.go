@@ -0,0 +1,96 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"github.com/jktr/go-strichliste/schema"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func at(day, hour int) schema.Timestamp {
+	return schema.Timestamp(time.Date(2020, 3, day, hour, 30, 0, 0, time.UTC))
+}
+
+// Transactions of all kinds, most recent first as the server lists them.
+func ledgerTransactions() []schema.Transaction {
+	alice := schema.User{ID: 1, Name: "alice"}
+	bob := schema.User{ID: 2, Name: "Bob: the Builder"}
+	mate := schema.Article{ID: 7, Name: "Club Mate 0,5l", Value: 150}
+	two := 2
+
+	return []schema.Transaction{
+		{ID: 6, Issuer: alice, Value: -300, TimeCreated: at(4, 9), Article: &mate, Quantity: &two, IsReversed: true},
+		{ID: 5, Issuer: bob, Value: 250, TimeCreated: at(3, 18), From: &alice, Comment: "for pizza"},
+		{ID: 4, Issuer: alice, Value: -250, TimeCreated: at(3, 18), To: &bob, Comment: "for pizza"},
+		{ID: 3, Issuer: alice, Value: -300, TimeCreated: at(2, 12), Article: &mate, Quantity: &two},
+		{ID: 2, Issuer: alice, Value: -200, TimeCreated: at(1, 20)},
+		{ID: 1, Issuer: alice, Value: 1000, TimeCreated: at(1, 8), Comment: "Bank transfer \"March\""},
+	}
+}
+
+func TestLedgerEncoder(t *testing.T) {
+	tests := []struct {
+		golden string
+		opt    Options
+		lopt   LedgerOptions
+	}{
+		{"all.ledger", Options{}, LedgerOptions{Format: FormatLedger}},
+		{"all.beancount", Options{}, LedgerOptions{Format: FormatBeancount}},
+		{"reversed.ledger", Options{}, LedgerOptions{Format: FormatLedger, KeepReversed: true}},
+		{"reversed.beancount", Options{}, LedgerOptions{Format: FormatBeancount, KeepReversed: true}},
+		{"user.ledger", Options{User: 2}, LedgerOptions{Format: FormatLedger}},
+		{"user.beancount", Options{User: 2}, LedgerOptions{Format: FormatBeancount}},
+		{"templates.beancount", Options{}, LedgerOptions{
+			Format:         FormatBeancount,
+			UserAccount:    "Liabilities:Members:{user_id}",
+			ArticleAccount: "Income:Sales:{article_id}",
+			Currency:       "CHF",
+		}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		enc, err := NewLedgerEncoder(&buf, &tt.opt, &tt.lopt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tx := range ledgerTransactions() {
+			// only the user's own transactions are listed for them
+			if tt.opt.User != 0 && tx.Issuer.ID != tt.opt.User {
+				continue
+			}
+			tx := tx
+			if err := enc.Encode(&tx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join("testdata", tt.golden)
+		if *update {
+			if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.golden, buf.Bytes(), want)
+		}
+	}
+}
+
+func TestNewLedgerEncoderUnknownFormat(t *testing.T) {
+	if _, err := NewLedgerEncoder(&bytes.Buffer{}, nil, &LedgerOptions{Format: 42}); err == nil {
+		t.Error("no error for an unknown format")
+	}
+}
@@ -0,0 +1,164 @@
+package strichliste
+
+import (
+	"context"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"time"
+)
+
+type (
+	// A PricePoint is the price of an article during the lifetime of
+	// one of its versions.
+	PricePoint struct {
+		ArticleID int // ID of the version
+		Name      string
+		Value     int
+		From      schema.Timestamp  // creation of the version
+		Until     *schema.Timestamp // creation of the next version; nil if current
+	}
+
+	// A PriceTimeline lists the prices of an article's versions,
+	// oldest first; see ArticleClient.PriceHistory.
+	PriceTimeline []PricePoint
+)
+
+// Returns the catalog from the cache if enabled, and retrieves all
+// articles otherwise; see WithArticleCache.
+func (s *ArticleClient) snapshot(ctx context.Context) (*articleCatalog, *Response, error) {
+	if s.client.articleCache.enabled {
+		cat, err := s.catalog(ctx)
+		return cat, nil, err
+	}
+	articles, resp, err := s.ListAll(ctx, nil)
+	if err != nil {
+		return nil, resp, err
+	}
+	return newArticleCatalog(articles), resp, nil
+}
+
+// GET /article
+// GET /article/{articleId}
+//   - ErrorArticleNotFound
+//
+// Retrieves all versions of an article, oldest first, given the ID of
+// any of them. Versions are linked via Article.Precursor; see Update.
+// Uses the article cache if enabled; see WithArticleCache.
+func (s *ArticleClient) History(ctx context.Context, id int) ([]schema.Article, *Response, error) {
+	cat, resp, err := s.snapshot(ctx)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	a, ok := cat.byID[id]
+	if !ok {
+		// not listed, but maybe still retrievable
+		p, resp, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, resp, err
+		}
+		a = *p
+	}
+
+	// the server only embeds the direct precursor, so walk them one by one
+	seen := map[int]bool{a.ID: true}
+	versions := []schema.Article{a}
+	for a.Precursor != nil && !seen[a.Precursor.ID] {
+		p, ok := cat.byID[a.Precursor.ID]
+		if !ok {
+			pp, resp, err := s.Get(ctx, a.Precursor.ID)
+			if err != nil {
+				return nil, resp, err
+			}
+			p = *pp
+		}
+		seen[p.ID] = true
+		versions = append(versions, p)
+		a = p
+	}
+
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+
+	for next, ok := cat.successors[id]; ok && !seen[next]; next, ok = cat.successors[next] {
+		succ, found := cat.byID[next]
+		if !found {
+			break
+		}
+		seen[next] = true
+		versions = append(versions, succ)
+	}
+
+	return versions, resp, nil
+}
+
+// GET /article
+// GET /article/{articleId}
+//   - ErrorArticleNotFound
+//   - ErrorArticleInactive
+//
+// Retrieves the active version of an article, given the ID of any of
+// its versions. Fails with ErrorArticleInactive if the most recent
+// version has been deactivated.
+func (s *ArticleClient) Current(ctx context.Context, id int) (*schema.Article, *Response, error) {
+	versions, resp, err := s.History(ctx, id)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	a := versions[len(versions)-1]
+	if !a.IsActive {
+		return nil, resp, fmt.Errorf("article %d has no active version: %w", id, schema.ErrorArticleInactive)
+	}
+	return &a, resp, nil
+}
+
+// GET /article
+// GET /article/{articleId}
+//   - ErrorArticleNotFound
+//
+// Retrieves the prices of all versions of an article, oldest first,
+// given the ID of any of them; see History.
+func (s *ArticleClient) PriceHistory(ctx context.Context, id int) (PriceTimeline, *Response, error) {
+	versions, resp, err := s.History(ctx, id)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	timeline := make(PriceTimeline, len(versions))
+	for i, a := range versions {
+		timeline[i] = PricePoint{ArticleID: a.ID, Name: a.Name, Value: a.Value, From: a.TimeCreated}
+		if i > 0 {
+			from := a.TimeCreated
+			timeline[i-1].Until = &from
+		}
+	}
+	return timeline, resp, nil
+}
+
+// Returns the price point that was current at the given time, e.g. a
+// Transaction.TimeCreated. Reports false if the time predates the
+// first version.
+func (t PriceTimeline) At(when schema.Timestamp) (PricePoint, bool) {
+	for i := len(t) - 1; i >= 0; i-- {
+		if !time.Time(when).Before(time.Time(t[i].From)) {
+			return t[i], true
+		}
+	}
+	return PricePoint{}, false
+}
+
+// Returns only the points at which the price changed, merging
+// consecutive versions that differ in name or barcode only.
+func (t PriceTimeline) Changes() PriceTimeline {
+	var changes PriceTimeline
+	for _, p := range t {
+		if n := len(changes); n > 0 && changes[n-1].Value == p.Value {
+			changes[n-1].Until = p.Until
+			continue
+		}
+		changes = append(changes, p)
+	}
+	return changes
+}
@@ -0,0 +1,179 @@
+package strichliste
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/jktr/go-strichliste/schema"
+	"strings"
+	"sync"
+)
+
+// The number of most recent transactions of a user that are searched
+// for an idempotency key before (re-)sending a transaction.
+const idempotencyLookback = 50
+
+// The comment tag name that carries idempotency keys.
+const idempotencyTag = "tx"
+
+type (
+	// Tracks transactions that are currently being created, so that
+	// concurrent creations with the same idempotency key (think
+	// double-taps) don't race each other past the server lookup.
+	idempotencyTracker struct {
+		mu     sync.Mutex
+		flying map[string]*idempotentCall
+	}
+
+	idempotentCall struct {
+		done chan struct{}
+		tx   *schema.Transaction
+		resp *Response
+		err  error
+	}
+)
+
+// Generates a new random idempotency key.
+// See TransactionContext.WithIdempotencyKey.
+func NewIdempotencyKey() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Set the idempotency key to use for transactions created with the
+// returned context. Use a fresh key, and thus a fresh context, for
+// each logical transaction.
+//
+// The key is embedded into the transaction's comment. Before sending
+// the transaction, and again if sending it failed in a way that leaves
+// its fate unknown, the user's recent transactions are searched for the
+// key. If a transaction with the key already exists, it is returned
+// instead of creating another one. This makes it safe to repeat a
+// timed-out or double-tapped Delta, Purchase, or TransferFunds with
+// the same key. Reverted transactions don't count, so repeating a
+// reverted transaction with its key creates it again.
+//
+// Keys must not contain whitespace or square brackets;
+// NewIdempotencyKey generates suitable ones.
+func (c *TransactionContext) WithIdempotencyKey(key string) *TransactionContext {
+	ctx := *c
+	ctx.idempotencyKey = key
+	return &ctx
+}
+
+// Extracts the idempotency key a transaction was created with.
+func IdempotencyKey(tx *schema.Transaction) (string, bool) {
+	return commentTag(tx.Comment, idempotencyTag)
+}
+
+// Creates a transaction unless one with the same idempotency key
+// has already been created.
+func (c *TransactionContext) createIdempotent(ctx context.Context, trc *schema.TransactionCreateRequest) (*schema.Transaction, *Response, error) {
+	key := c.idempotencyKey
+	tagged := *trc
+	tagged.Comment = withCommentTag(trc.Comment, idempotencyTag, key)
+
+	call, leader := c.client.idempotency.join(key)
+	if !leader {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-call.done:
+		}
+		if call.err == nil {
+			return call.tx, call.resp, nil
+		}
+		// the other attempt failed; make our own
+		return c.createIdempotent(ctx, trc)
+	}
+	defer c.client.idempotency.leave(key, call)
+
+	tx, resp, err := c.findByIdempotencyKey(ctx, key)
+	if err != nil || tx != nil {
+		call.tx, call.resp, call.err = tx, resp, err
+		return tx, resp, err
+	}
+
+	tx, resp, err = c.create(ctx, &tagged)
+	if err != nil && isAmbiguous(err) {
+		// the transaction may have landed anyway
+		if found, fresp, ferr := c.findByIdempotencyKey(ctx, key); ferr == nil && found != nil {
+			tx, resp, err = found, fresp, nil
+		}
+	}
+
+	call.tx, call.resp, call.err = tx, resp, err
+	return tx, resp, err
+}
+
+// Searches the user's recent transactions for an idempotency key,
+// skipping reverted ones. Returns a nil transaction if none was found.
+func (c *TransactionContext) findByIdempotencyKey(ctx context.Context, key string) (*schema.Transaction, *Response, error) {
+	txs, resp, err := c.List(ctx, &ListOpts{PerPage: idempotencyLookback})
+	if err != nil {
+		return nil, resp, err
+	}
+	for i := range txs {
+		if k, ok := IdempotencyKey(&txs[i]); ok && k == key && !txs[i].IsReversed {
+			return &txs[i], resp, nil
+		}
+	}
+	return nil, resp, nil
+}
+
+// Reports whether a failed request may nevertheless have been
+// processed by the server, i.e. whether no API error was returned.
+func isAmbiguous(err error) bool {
+	return ErrorClassOf(err) == ""
+}
+
+// Registers a call for the key. Reports whether the caller leads the
+// call; otherwise, the caller should wait for the returned call.
+func (t *idempotencyTracker) join(key string) (*idempotentCall, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if call, ok := t.flying[key]; ok {
+		return call, false
+	}
+	if t.flying == nil {
+		t.flying = make(map[string]*idempotentCall)
+	}
+	call := &idempotentCall{done: make(chan struct{})}
+	t.flying[key] = call
+	return call, true
+}
+
+func (t *idempotencyTracker) leave(key string, call *idempotentCall) {
+	t.mu.Lock()
+	delete(t.flying, key)
+	t.mu.Unlock()
+	close(call.done)
+}
+
+// Appends a machine-readable "[name:value]" tag to a comment.
+func withCommentTag(comment, name, value string) string {
+	tag := "[" + name + ":" + value + "]"
+	if comment == "" {
+		return tag
+	}
+	return comment + " " + tag
+}
+
+// Extracts the value of the last "[name:value]" tag from a comment.
+func commentTag(comment, name string) (string, bool) {
+	prefix := "[" + name + ":"
+	i := strings.LastIndex(comment, prefix)
+	if i < 0 {
+		return "", false
+	}
+	value := comment[i+len(prefix):]
+	j := strings.IndexByte(value, ']')
+	if j < 0 {
+		return "", false
+	}
+	return value[:j], true
+}
@@ -0,0 +1,199 @@
+package strichliste_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/strichlistetest"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Passes requests on, but loses the responses to the first few
+// transactions created, or holds them back for a while.
+type flakyCreate struct {
+	mu    sync.Mutex
+	lose  int
+	delay time.Duration
+	posts int
+}
+
+func (f *flakyCreate) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if req.Method != http.MethodPost || err != nil {
+		return resp, err
+	}
+
+	f.mu.Lock()
+	f.posts++
+	lose := f.lose > 0
+	f.lose--
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+	if lose {
+		resp.Body.Close()
+		return nil, errors.New("connection reset")
+	}
+	return resp, nil
+}
+
+func (f *flakyCreate) created() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.posts
+}
+
+func balance(t *testing.T, srv *strichlistetest.Server, id int) int {
+	t.Helper()
+	u, _, err := srv.NewClient().User.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Balance
+}
+
+func TestIdempotencyLostResponse(t *testing.T) {
+	ctx := context.Background()
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+
+	flaky := &flakyCreate{lose: 1}
+	client := srv.NewClient(strichliste.WithTransport(flaky))
+	u := srv.AddUser("alice", 0)
+	tc := client.Transaction.Context(u.ID).WithComment("Club Mate").WithIdempotencyKey("k1")
+
+	// the deposit landed, so it is found despite the lost response
+	tx, _, err := tc.Delta(ctx, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, ok := strichliste.IdempotencyKey(tx); !ok || key != "k1" {
+		t.Errorf("key = %q, %v, want k1", key, ok)
+	}
+
+	// and retrying returns it instead of depositing again
+	again, _, err := tc.Delta(ctx, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != tx.ID {
+		t.Errorf("retry created transaction %d, want %d", again.ID, tx.ID)
+	}
+	if flaky.created() != 1 || balance(t, srv, u.ID) != 500 {
+		t.Errorf("%d transactions sent, balance %d, want 1 and 500", flaky.created(), balance(t, srv, u.ID))
+	}
+
+	// other keys create other transactions
+	if _, _, err := tc.WithIdempotencyKey("k2").Delta(ctx, 500); err != nil {
+		t.Fatal(err)
+	}
+	if got := balance(t, srv, u.ID); got != 1000 {
+		t.Errorf("balance %d, want 1000", got)
+	}
+}
+
+func TestIdempotencyConcurrent(t *testing.T) {
+	ctx := context.Background()
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+
+	flaky := &flakyCreate{delay: 50 * time.Millisecond}
+	client := srv.NewClient(strichliste.WithTransport(flaky))
+	u := srv.AddUser("alice", 0)
+	tc := client.Transaction.Context(u.ID).WithIdempotencyKey("double-tap")
+
+	const n = 10
+	ids := make([]int, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tx, _, err := tc.Delta(ctx, 500)
+			if err == nil {
+				ids[i] = tx.ID
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range ids {
+		if errs[i] != nil {
+			t.Errorf("call %d: %v", i, errs[i])
+		} else if ids[i] != ids[0] {
+			t.Errorf("call %d: transaction %d, want %d", i, ids[i], ids[0])
+		}
+	}
+	if flaky.created() != 1 || balance(t, srv, u.ID) != 500 {
+		t.Errorf("%d transactions sent, balance %d, want 1 and 500", flaky.created(), balance(t, srv, u.ID))
+	}
+}
+
+func TestIdempotencyLookback(t *testing.T) {
+	ctx := context.Background()
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	u := srv.AddUser("alice", 0)
+	tc := client.Transaction.Context(u.ID)
+
+	first, _, err := tc.WithIdempotencyKey("old").Delta(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// push the key out of the searched transactions (idempotencyLookback)
+	for i := 0; i < 50; i++ {
+		if _, _, err := tc.Delta(ctx, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// keys are only recognized among the most recent transactions
+	second, _, err := tc.WithIdempotencyKey("old").Delta(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID == first.ID {
+		t.Error("key found beyond the lookback")
+	}
+	if got := balance(t, srv, u.ID); got != 100+50*10+100 {
+		t.Errorf("balance %d, want %d", got, 100+50*10+100)
+	}
+}
+
+func TestIdempotencyReverted(t *testing.T) {
+	ctx := context.Background()
+	srv := strichlistetest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	u := srv.AddUser("alice", 0)
+	tc := client.Transaction.Context(u.ID).WithIdempotencyKey("k")
+
+	tx, _, err := tc.Delta(ctx, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tc.Revert(ctx, tx.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// a reverted transaction doesn't count as created
+	again, _, err := tc.Delta(ctx, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID == tx.ID || again.IsReversed {
+		t.Errorf("got %+v, want a new transaction", again)
+	}
+	if got := balance(t, srv, u.ID); got != 500 {
+		t.Errorf("balance %d, want 500", got)
+	}
+}
@@ -1,6 +1,7 @@
 package strichliste
 
 import (
+	"context"
 	"fmt"
 	"github.com/jktr/go-strichliste/schema"
 	"net/http"
@@ -15,10 +16,10 @@ type MetricsClient struct {
 // GET /metrics
 //
 // Retrieves the current server metrics.
-func (s *MetricsClient) ForSystem() (*schema.SystemMetrics, *Response, error) {
+func (s *MetricsClient) ForSystem(ctx context.Context) (*schema.SystemMetrics, *Response, error) {
 	path := schema.EndpointMetrics
 
-	req, err := s.client.NewRequest(http.MethodGet, path, nil)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -35,10 +36,10 @@ func (s *MetricsClient) ForSystem() (*schema.SystemMetrics, *Response, error) {
 // GET /user/{userId}/metrics
 //
 // Retrieves the current user metrics by user ID.
-func (s *MetricsClient) ForUser(id int) (*schema.UserMetrics, *Response, error) {
+func (s *MetricsClient) ForUser(ctx context.Context, id int) (*schema.UserMetrics, *Response, error) {
 	path := fmt.Sprintf("%s/%d%s", schema.EndpointUser, id, schema.EndpointMetrics)
 
-	req, err := s.client.NewRequest(http.MethodGet, path, nil)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
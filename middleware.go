@@ -0,0 +1,61 @@
+package strichliste
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+type (
+	// Performs a single HTTP round trip, like http.Client.Do.
+	RoundTripFunc func(*http.Request) (*http.Response, error)
+
+	// A Middleware wraps the round trip of every attempt made by
+	// Client.Do. It may inspect or modify the request before passing
+	// it on to next, and inspect or modify the response afterwards.
+	Middleware func(next RoundTripFunc) RoundTripFunc
+)
+
+// Register middleware that wraps every request made by the client.
+// Middleware registered first sees requests first, and responses last.
+// May be passed multiple times.
+func WithMiddleware(middleware ...Middleware) ClientOption {
+	return func(client *Client) {
+		client.middleware = append(client.middleware, middleware...)
+	}
+}
+
+func chain(rt RoundTripFunc, middleware []Middleware) RoundTripFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		rt = middleware[i](rt)
+	}
+	return rt
+}
+
+// Middleware that sets a header on every request.
+func SetHeader(key, value string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set(key, value)
+			return next(req)
+		}
+	}
+}
+
+// Middleware that logs every request's method, URL, outcome,
+// and duration. Credentials are never logged.
+func LogRequests(logger *log.Logger) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			took := time.Since(start).Round(time.Millisecond)
+			if err != nil {
+				logger.Printf("%s %s: %s (%s)", req.Method, redactURL(req.URL), err, took)
+			} else {
+				logger.Printf("%s %s: %s (%s)", req.Method, redactURL(req.URL), resp.Status, took)
+			}
+			return resp, err
+		}
+	}
+}
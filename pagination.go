@@ -0,0 +1,218 @@
+package strichliste
+
+import (
+	"context"
+	"errors"
+	"github.com/jktr/go-strichliste/schema"
+)
+
+// The page size used by iterators unless configured otherwise.
+const DefaultPerPage = 100
+
+// Returned by iterators when an endpoint ignores both the offset and
+// the page number of ListOpts and serves the first page again, so that
+// the remaining items can't be reached. The server's list endpoints
+// honor the offset, so this indicates an incompatible server. Setting
+// IterOpts.PerPage above the number of items works around it.
+var ErrPaginationUnsupported = errors.New("endpoint ignores the page offset")
+
+type (
+	// Configures iteration over all pages of a list endpoint.
+	IterOpts struct {
+		PerPage  uint // items per page (0 means DefaultPerPage)
+		MaxItems uint // stop after this many items (0 means no limit)
+	}
+
+	// Walks the pages of a list endpoint; the typed iterators
+	// below wrap this with a buffer for the current page.
+	pager struct {
+		opts    IterOpts
+		page    uint // number of the current page
+		key     int  // ID of the first item of the current page
+		size    int  // items on the current page
+		cur     int  // index of the current item on the current page
+		yielded uint // items yielded so far
+		last    bool // whether the current page is the last one
+		resp    *Response
+		err     error
+	}
+
+	// Loads a page into a typed iterator's buffer; returns the number
+	// of items on the page and the ID of the first one.
+	pageLoader func(ctx context.Context, opt *ListOpts) (int, int, *Response, error)
+
+	// Iterates over users, fetching pages as needed.
+	//
+	//	it := client.User.ListIter(nil)
+	//	for it.Next(ctx) {
+	//		user := it.User()
+	//	}
+	//	if err := it.Err(); err != nil {
+	//	}
+	UserIterator struct {
+		pager
+		list  func(context.Context, *ListOpts) ([]schema.User, *Response, error)
+		users []schema.User
+	}
+
+	// Iterates over articles, fetching pages as needed.
+	// See UserIterator for usage.
+	ArticleIterator struct {
+		pager
+		list     func(context.Context, *ListOpts) ([]schema.Article, *Response, error)
+		articles []schema.Article
+	}
+
+	// Iterates over transactions, fetching pages as needed.
+	// See UserIterator for usage.
+	TransactionIterator struct {
+		pager
+		list         func(context.Context, *ListOpts) ([]schema.Transaction, *Response, error)
+		transactions []schema.Transaction
+	}
+)
+
+func newPager(opt *IterOpts) pager {
+	p := pager{cur: -1}
+	if opt != nil {
+		p.opts = *opt
+	}
+	if p.opts.PerPage == 0 {
+		p.opts.PerPage = DefaultPerPage
+	}
+	return p
+}
+
+// Advances to the next item, loading the next page if necessary.
+// Iteration stops at the last page, which is the first one that is
+// not full, or when MaxItems have been yielded. A page with more items
+// than requested is the last one, as the endpoint ignored the limit
+// and served all items. Fails with ErrPaginationUnsupported if a page
+// repeats the previous one.
+func (p *pager) next(ctx context.Context, load pageLoader) bool {
+	if p.err != nil {
+		return false
+	}
+	if p.opts.MaxItems > 0 && p.yielded >= p.opts.MaxItems {
+		return false
+	}
+
+	p.cur++
+	if p.cur >= p.size {
+		if p.last {
+			return false
+		}
+
+		p.page++
+		n, key, resp, err := load(ctx, &ListOpts{Page: p.page, PerPage: p.opts.PerPage})
+		p.resp = resp
+		if err != nil {
+			p.err = err
+			return false
+		}
+
+		// guard against endpoints that ignore the offset
+		if n > 0 && p.page > 1 && key == p.key {
+			p.err = ErrPaginationUnsupported
+			return false
+		}
+
+		p.size, p.cur, p.key = n, 0, key
+		p.last = uint(n) != p.opts.PerPage
+		if n == 0 {
+			p.last = true
+			return false
+		}
+	}
+
+	p.yielded++
+	return true
+}
+
+// Returns the response of the most recently fetched page.
+func (p *pager) Response() *Response {
+	return p.resp
+}
+
+// Returns the error that stopped iteration, if any.
+func (p *pager) Err() error {
+	return p.err
+}
+
+// Advances to the next user. Returns false when there are no more
+// users or an error occurred; see Err.
+func (it *UserIterator) Next(ctx context.Context) bool {
+	return it.next(ctx, func(ctx context.Context, opt *ListOpts) (int, int, *Response, error) {
+		users, resp, err := it.list(ctx, opt)
+		it.users = users
+		if len(users) == 0 {
+			return 0, 0, resp, err
+		}
+		return len(users), users[0].ID, resp, err
+	})
+}
+
+// Returns the current user.
+func (it *UserIterator) User() schema.User {
+	return it.users[it.cur]
+}
+
+// Advances to the next article. Returns false when there are no more
+// articles or an error occurred; see Err.
+func (it *ArticleIterator) Next(ctx context.Context) bool {
+	return it.next(ctx, func(ctx context.Context, opt *ListOpts) (int, int, *Response, error) {
+		articles, resp, err := it.list(ctx, opt)
+		it.articles = articles
+		if len(articles) == 0 {
+			return 0, 0, resp, err
+		}
+		return len(articles), articles[0].ID, resp, err
+	})
+}
+
+// Returns the current article.
+func (it *ArticleIterator) Article() schema.Article {
+	return it.articles[it.cur]
+}
+
+// Advances to the next transaction. Returns false when there are no
+// more transactions or an error occurred; see Err.
+func (it *TransactionIterator) Next(ctx context.Context) bool {
+	return it.next(ctx, func(ctx context.Context, opt *ListOpts) (int, int, *Response, error) {
+		transactions, resp, err := it.list(ctx, opt)
+		it.transactions = transactions
+		if len(transactions) == 0 {
+			return 0, 0, resp, err
+		}
+		return len(transactions), transactions[0].ID, resp, err
+	})
+}
+
+// Returns the current transaction.
+func (it *TransactionIterator) Transaction() schema.Transaction {
+	return it.transactions[it.cur]
+}
+
+func allUsers(ctx context.Context, it *UserIterator) ([]schema.User, *Response, error) {
+	var users []schema.User
+	for it.Next(ctx) {
+		users = append(users, it.User())
+	}
+	return users, it.Response(), it.Err()
+}
+
+func allArticles(ctx context.Context, it *ArticleIterator) ([]schema.Article, *Response, error) {
+	var articles []schema.Article
+	for it.Next(ctx) {
+		articles = append(articles, it.Article())
+	}
+	return articles, it.Response(), it.Err()
+}
+
+func allTransactions(ctx context.Context, it *TransactionIterator) ([]schema.Transaction, *Response, error) {
+	var transactions []schema.Transaction
+	for it.Next(ctx) {
+		transactions = append(transactions, it.Transaction())
+	}
+	return transactions, it.Response(), it.Err()
+}
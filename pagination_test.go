@@ -0,0 +1,86 @@
+package strichliste
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPager(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	// serves items by page, or always the first page if ignorePage,
+	// or all items if ignoreLimit
+	loader := func(ignorePage, ignoreLimit bool, got *[]int) pageLoader {
+		return func(ctx context.Context, opt *ListOpts) (int, int, *Response, error) {
+			from := int((opt.Page - 1) * opt.PerPage)
+			if ignorePage {
+				from = 0
+			}
+			to := from + int(opt.PerPage)
+			if ignoreLimit {
+				from, to = 0, len(items)
+			}
+			if from > len(items) {
+				from = len(items)
+			}
+			if to > len(items) {
+				to = len(items)
+			}
+			*got = items[from:to]
+			if from == to {
+				return 0, 0, nil, nil
+			}
+			return to - from, items[from], nil, nil
+		}
+	}
+
+	tests := []struct {
+		name        string
+		opt         IterOpts
+		ignorePage  bool
+		ignoreLimit bool
+		want        []int
+		err         error
+	}{
+		{"one page", IterOpts{PerPage: 10}, false, false, items, nil},
+		{"several pages", IterOpts{PerPage: 2}, false, false, items, nil},
+		{"exactly full pages", IterOpts{PerPage: 5}, false, false, items, nil},
+		{"limited", IterOpts{PerPage: 2, MaxItems: 3}, false, false, items[:3], nil},
+		{"page ignored", IterOpts{PerPage: 2}, true, false, items[:2], ErrPaginationUnsupported},
+		{"page ignored, but all on one page", IterOpts{PerPage: 10}, true, false, items, nil},
+		{"limit ignored", IterOpts{PerPage: 2}, false, true, items, nil},
+	}
+	for _, tt := range tests {
+		p := newPager(&tt.opt)
+		var page, got []int
+		load := loader(tt.ignorePage, tt.ignoreLimit, &page)
+		for p.next(context.Background(), load) {
+			got = append(got, page[p.cur])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		if !errors.Is(p.Err(), tt.err) || (tt.err == nil && p.Err() != nil) {
+			t.Errorf("%s: err = %v, want %v", tt.name, p.Err(), tt.err)
+		}
+	}
+}
+
+func TestListOptsValues(t *testing.T) {
+	tests := []struct {
+		opt  *ListOpts
+		want string
+	}{
+		{nil, ""},
+		{&ListOpts{PerPage: 10}, "limit=10"},
+		{&ListOpts{Page: 1, PerPage: 10}, "limit=10&offset=0&page=1"},
+		{&ListOpts{Page: 3, PerPage: 10}, "limit=10&offset=20&page=3"},
+	}
+	for _, tt := range tests {
+		if got := tt.opt.values().Encode(); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.opt, got, tt.want)
+		}
+	}
+}
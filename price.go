@@ -0,0 +1,48 @@
+package strichliste
+
+import (
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"strings"
+)
+
+// The comment tag name that carries the reason for a price override.
+const priceOverrideTag = "price"
+
+// A PriceOverride describes a purchase that was made at a custom price;
+// see TransactionContext.PurchaseAt.
+type PriceOverride struct {
+	Price     int // charged per article
+	ListPrice int // the article's value at the time of purchase
+	Reason    string
+}
+
+// Extracts the price override of a purchase made with PurchaseAt.
+// Reports false for other transactions.
+func PriceOverrideOf(tx *schema.Transaction) (*PriceOverride, bool) {
+	reason, ok := commentTag(tx.Comment, priceOverrideTag)
+	if !ok || tx.Article == nil || tx.Quantity == nil || *tx.Quantity < 1 {
+		return nil, false
+	}
+	return &PriceOverride{
+		Price:     -tx.Value / *tx.Quantity,
+		ListPrice: tx.Article.Value,
+		Reason:    reason,
+	}, true
+}
+
+// The server charges the article's value if the amount is zero, and
+// credits the user if it's positive, so only positive prices are valid.
+func checkPriceOverride(count, price int, reason string) error {
+	switch {
+	case count < 1:
+		return fmt.Errorf("invalid quantity %d: %w", count, schema.ErrorParameterInvalid)
+	case price < 1:
+		return fmt.Errorf("invalid price %d, must be positive: %w", price, schema.ErrorParameterInvalid)
+	case strings.TrimSpace(reason) == "":
+		return fmt.Errorf("missing price override reason: %w", schema.ErrorParameterMissing)
+	case strings.ContainsAny(reason, "[]\n"):
+		return fmt.Errorf("invalid price override reason %q: %w", reason, schema.ErrorParameterInvalid)
+	}
+	return nil
+}
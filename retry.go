@@ -0,0 +1,160 @@
+package strichliste
+
+import (
+	"context"
+	"github.com/jktr/go-strichliste/schema"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type (
+	// A RetryPolicy configures how Client.Do retries failed attempts.
+	//
+	// Delays grow exponentially from MinBackoff up to MaxBackoff;
+	// each delay is randomized to somewhere between half and all of
+	// its nominal value, so that many terminals recovering from the
+	// same outage don't hammer the server in lockstep.
+	RetryPolicy struct {
+		MaxRetries int           // retries after the first attempt
+		MinBackoff time.Duration // nominal delay before the first retry
+		MaxBackoff time.Duration // upper bound for any delay
+
+		// Decides whether a failed attempt may be retried.
+		// Nil means DefaultRetryable.
+		Retryable func(*RetryAttempt) bool
+	}
+
+	// A RetryAttempt describes a failed attempt of an API call.
+	RetryAttempt struct {
+		Method     string            // GET/POST/DELETE/etc.
+		Path       string            // API path, e.g. "/article/42"
+		Attempt    int               // number of the failed attempt (1-indexed)
+		StatusCode int               // 0 if no response was received
+		Class      schema.ErrorClass // empty if the server sent no API error
+		Err        error
+	}
+)
+
+// The RetryPolicy used by WithRetries.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 250 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+}
+
+// Configure a policy for retrying failed API calls.
+// Not setting this option will make exactly one attempt per call.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(client *Client) {
+		client.retryPolicy = &policy
+	}
+}
+
+// Configure retries of failed API calls via DefaultRetryPolicy.
+func WithRetries() ClientOption {
+	return WithRetryPolicy(DefaultRetryPolicy)
+}
+
+// Reports whether the API call is safe to repeat, i.e. whether it
+// can't have side effects beyond those of its first successful attempt.
+//
+// This is the case for GET requests, and for DELETE on articles,
+// which only deactivates them. Creating transactions is never
+// idempotent, and neither is reverting them.
+func IsIdempotent(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodDelete:
+		return strings.HasPrefix(path, schema.EndpointArticle+"/")
+	}
+	return false
+}
+
+// Retries idempotent API calls (see IsIdempotent) that failed due to
+// temporary errors (see IsTemporary). API errors like
+// ErrorUserNotFound are deterministic and won't go away on their own,
+// so those aren't retried.
+func DefaultRetryable(a *RetryAttempt) bool {
+	return IsIdempotent(a.Method, a.Path) && IsTemporary(a.Err)
+}
+
+// Returns the delay before the next attempt, and whether there
+// should be one at all.
+func (p *RetryPolicy) backoff(a *RetryAttempt) (time.Duration, bool) {
+	if p == nil || a.Attempt > p.MaxRetries {
+		return 0, false
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	if !retryable(a) {
+		return 0, false
+	}
+
+	d := p.MinBackoff
+	for i := 1; i < a.Attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d > 1 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+	return d, true
+}
+
+func (c *Client) retryAttempt(req *http.Request, resp *Response, attempt int, err error) *RetryAttempt {
+	a := &RetryAttempt{
+		Method:  req.Method,
+		Path:    c.apiPath(req.URL),
+		Attempt: attempt,
+		Class:   ErrorClassOf(err),
+		Err:     err,
+	}
+	if resp != nil {
+		a.StatusCode = resp.StatusCode
+	}
+	return a
+}
+
+// Strips the endpoint's path prefix and any query from an API URL.
+func (c *Client) apiPath(u *url.URL) string {
+	path := u.Path
+	if e, err := url.Parse(c.endpoint); err == nil {
+		path = strings.TrimPrefix(path, e.Path)
+	}
+	return path
+}
+
+// Waits for the given duration, unless the context is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Prepares an already sent request for being sent again.
+func rewind(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
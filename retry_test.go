@@ -0,0 +1,199 @@
+package strichliste
+
+import (
+	"context"
+	"errors"
+	"github.com/jktr/go-strichliste/schema"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	errUnavailable = &Error{StatusCode: http.StatusServiceUnavailable, Err: ErrUnexpectedStatus}
+	errUserUnknown = &Error{StatusCode: http.StatusInternalServerError, Err: &schema.ErrorResponse{Class: schema.ErrorUserNotFound}}
+)
+
+func TestBackoff(t *testing.T) {
+	p := &RetryPolicy{MaxRetries: 5, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	nominal := []time.Duration{100, 200, 400, 800, 1000}
+
+	for i, n := range nominal {
+		n *= time.Millisecond
+		for j := 0; j < 100; j++ {
+			d, ok := p.backoff(&RetryAttempt{Method: http.MethodGet, Attempt: i + 1, Err: errUnavailable})
+			if !ok || d < n/2 || d > n {
+				t.Fatalf("attempt %d: delay %v, %v, want between %v and %v", i+1, d, ok, n/2, n)
+			}
+		}
+	}
+
+	if _, ok := p.backoff(&RetryAttempt{Method: http.MethodGet, Attempt: 6, Err: errUnavailable}); ok {
+		t.Error("retried beyond MaxRetries")
+	}
+	if _, ok := (*RetryPolicy)(nil).backoff(&RetryAttempt{Method: http.MethodGet, Attempt: 1, Err: errUnavailable}); ok {
+		t.Error("retried without a policy")
+	}
+	if _, ok := p.backoff(&RetryAttempt{Method: http.MethodGet, Attempt: 1, Err: errUserUnknown}); ok {
+		t.Error("retried an API error")
+	}
+
+	// without a cap, delays keep growing
+	p = &RetryPolicy{MaxRetries: 20, MinBackoff: time.Millisecond}
+	if d, _ := p.backoff(&RetryAttempt{Method: http.MethodGet, Attempt: 12, Err: errUnavailable}); d < 1024*time.Millisecond {
+		t.Errorf("uncapped delay %v, want at least %v", d, 1024*time.Millisecond)
+	}
+
+	// jitter spreads delays out
+	p = &RetryPolicy{MaxRetries: 1, MinBackoff: time.Second}
+	seen := make(map[time.Duration]bool)
+	for j := 0; j < 100; j++ {
+		d, _ := p.backoff(&RetryAttempt{Method: http.MethodGet, Attempt: 1, Err: errUnavailable})
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Error("delays aren't randomized")
+	}
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	canceled := &Error{StatusCode: http.StatusServiceUnavailable, Err: context.Canceled}
+
+	tests := []struct {
+		method, path string
+		err          error
+		want         bool
+	}{
+		{http.MethodGet, "/user/1", errUnavailable, true},
+		{http.MethodGet, "/user/1", errUserUnknown, false},
+		{http.MethodGet, "/user/1", canceled, false},
+		{http.MethodGet, "/user/1", context.DeadlineExceeded, false},
+		{http.MethodDelete, "/article/3", errUnavailable, true},
+		{http.MethodPost, "/user/1/transaction", errUnavailable, false},
+		{http.MethodDelete, "/user/1/transaction/5", errUnavailable, false},
+		{http.MethodPost, "/article", errUnavailable, false},
+		{http.MethodPost, "/article/3", errUnavailable, false},
+		{http.MethodPost, "/user", errUnavailable, false},
+	}
+	for _, tt := range tests {
+		a := &RetryAttempt{Method: tt.method, Path: tt.path, Attempt: 1, Err: tt.err}
+		if got := DefaultRetryable(a); got != tt.want {
+			t.Errorf("%s %s (%v): got %v, want %v", tt.method, tt.path, tt.err, got, tt.want)
+		}
+	}
+}
+
+// Fails the first requests with 503, recording the bodies it receives.
+type flakyServer struct {
+	mu     sync.Mutex
+	fail   int
+	bodies []string
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(body))
+	fail := len(s.bodies) <= s.fail
+	s.mu.Unlock()
+
+	if fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{}`))
+}
+
+func TestRewind(t *testing.T) {
+	c := NewClient()
+	req, err := c.NewRequest(context.Background(), http.MethodPost, "/user", &schema.UserCreateRequest{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ioutil.ReadAll(req.Body)
+
+	if err := rewind(req); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadAll(req.Body); string(got) != string(want) || len(got) == 0 {
+		t.Errorf("rewound body %q, want %q", got, want)
+	}
+
+	// requests without a body stay as they are
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost/user", nil)
+	if err := rewind(req); err != nil || req.Body != nil {
+		t.Errorf("rewinding no body: %v, %v", req.Body, err)
+	}
+}
+
+func TestRetryRewindsBody(t *testing.T) {
+	srv := &flakyServer{fail: 2}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c := NewClient(WithEndpoint(ts.URL), WithRetryPolicy(RetryPolicy{
+		MaxRetries: 3,
+		MinBackoff: time.Millisecond,
+		Retryable:  func(*RetryAttempt) bool { return true },
+	}))
+	req, err := c.NewRequest(context.Background(), http.MethodPost, "/user", &schema.UserCreateRequest{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(srv.bodies) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(srv.bodies))
+	}
+	for i, b := range srv.bodies {
+		if b == "" || b != srv.bodies[0] {
+			t.Errorf("attempt %d: body %q, want %q", i+1, b, srv.bodies[0])
+		}
+	}
+}
+
+func TestRetryNeverRepeatsTransactions(t *testing.T) {
+	srv := &flakyServer{fail: 1}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c := NewClient(WithEndpoint(ts.URL), WithRetryPolicy(RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond}))
+	ctx := context.Background()
+
+	tests := []struct {
+		method, path string
+	}{
+		{http.MethodPost, "/user/1/transaction"},
+		{http.MethodDelete, "/user/1/transaction/5"},
+	}
+	for _, tt := range tests {
+		srv.bodies, srv.fail = nil, 1
+		req, err := c.NewRequest(ctx, tt.method, tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Do(req, nil); !errors.Is(err, ErrUnexpectedStatus) {
+			t.Errorf("%s %s: err = %v, want %v", tt.method, tt.path, err, ErrUnexpectedStatus)
+		}
+		if len(srv.bodies) != 1 {
+			t.Errorf("%s %s: %d attempts, want 1", tt.method, tt.path, len(srv.bodies))
+		}
+	}
+
+	// whereas reads are retried
+	srv.bodies, srv.fail = nil, 1
+	req, err := c.NewRequest(ctx, http.MethodGet, "/user/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(req, nil); err != nil || len(srv.bodies) != 2 {
+		t.Errorf("GET: %d attempts, err %v, want 2 attempts", len(srv.bodies), err)
+	}
+}
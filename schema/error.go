@@ -21,6 +21,12 @@ type (
 	// Aliasing string allows us to implement a coustom JSON
 	// Decoder that parses error paths like
 	// "App\\Exception\\FooBar" as "FooBar"
+	//
+	// ErrorClass also implements error, so the constants above are
+	// sentinel values that work with errors.Is:
+	//
+	//	if errors.Is(err, schema.ErrorUserNotFound) {
+	//	}
 	ErrorClass string
 
 	// Structure of an API error
@@ -30,10 +36,9 @@ type (
 
 		// HTTP error code. Note that the server actually
 		// returns 500 and only sets this code in its JSON
-		// response. We omit this because of this behaviour,
-		// and because Class already uniquely identifies an
-		// error type
-		code int `json:"code"`
+		// response. Class already uniquely identifies an
+		// error type, so this is mostly useful for diagnostics.
+		Code int `json:"code"`
 	}
 
 	SingleErrorResponse struct {
@@ -45,6 +50,22 @@ func (e ErrorResponse) Error() string {
 	return e.Message
 }
 
+// Reports whether the error is of the target's class; the target may
+// be an ErrorClass or an ErrorResponse.
+func (e ErrorResponse) Is(target error) bool {
+	switch t := target.(type) {
+	case ErrorClass:
+		return e.Class == t
+	case *ErrorResponse:
+		return t != nil && e.Class == t.Class
+	}
+	return false
+}
+
+func (e ErrorClass) Error() string {
+	return string(e)
+}
+
 func (e *ErrorClass) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), "\"")
 	ss := strings.Split(s, "\\")
@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Units of the intervals that the server's settings use. Months and
+// years are unsupported, as their length varies.
+var intervalUnits = map[string]time.Duration{
+	"sec":       time.Second,
+	"second":    time.Second,
+	"min":       time.Minute,
+	"minute":    time.Minute,
+	"hour":      time.Hour,
+	"day":       24 * time.Hour,
+	"week":      7 * 24 * time.Hour,
+	"fortnight": 14 * 24 * time.Hour,
+}
+
+// Parses relative intervals in the style of PHP's strtotime, like
+// "10 day", "5 minutes", "+1 week", or "1 day 12 hours", as used by
+// Settings.User.StalePeriod and Settings.Payment.Reverse.Timeout.
+//
+// Units may be singular or plural; months and years aren't supported
+// because their length varies.
+func ParseInterval(s string) (time.Duration, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("invalid interval: %q", s)
+	}
+
+	var d time.Duration
+	for len(fields) > 0 {
+		// allow both "5 minute" and "5minute"
+		num, unit := fields[0], ""
+		if i := strings.IndexFunc(num, func(r rune) bool { return r >= 'a' && r <= 'z' }); i > 0 {
+			num, unit = num[:i], num[i:]
+			fields = fields[1:]
+		} else if len(fields) >= 2 {
+			unit = fields[1]
+			fields = fields[2:]
+		} else {
+			return 0, fmt.Errorf("invalid interval: %q: missing unit", s)
+		}
+
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval: %q: bad number %q", s, num)
+		}
+
+		u, ok := intervalUnits[unit]
+		if !ok {
+			u, ok = intervalUnits[strings.TrimSuffix(unit, "s")]
+		}
+		if !ok {
+			return 0, fmt.Errorf("invalid interval: %q: unsupported unit %q", s, unit)
+		}
+		d += time.Duration(n) * u
+	}
+	return d, nil
+}
+
+// Returns the location of the server's timezone, falling back to the
+// local timezone if it is unset or unknown.
+func (s *Settings) Location() *time.Location {
+	if s.I18n.Timezone != "" {
+		if loc, err := time.LoadLocation(s.I18n.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// Converts a timestamp to time.Time in the server's timezone;
+// see Timestamp.In.
+func (s *Settings) Time(t Timestamp) time.Time {
+	return t.In(s.Location())
+}
+
+// Returns the parsed Settings.User.StalePeriod.
+func (s *Settings) StalePeriod() (time.Duration, error) {
+	return ParseInterval(s.User.StalePeriod)
+}
+
+// Returns the parsed Settings.Payment.Reverse.Timeout.
+func (s *Settings) ReverseTimeout() (time.Duration, error) {
+	return ParseInterval(s.Payment.Reverse.Timeout)
+}
+
+// Reports whether a user hasn't been updated for longer than the
+// stale period; any transaction updates the user.
+func (s *Settings) IsStale(u *User, now time.Time) (bool, error) {
+	period, err := s.StalePeriod()
+	if err != nil {
+		return false, err
+	}
+	return now.Sub(s.Time(u.TimeUpdated)) > period, nil
+}
+
+// Returns the time at which a transaction can no longer be reverted.
+func (s *Settings) UndoDeadline(tx *Transaction) (time.Time, error) {
+	timeout, err := s.ReverseTimeout()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Time(tx.TimeCreated).Add(timeout), nil
+}
+
+// Reports whether a transaction can still be reverted at the given
+// time, i.e. whether reverting is enabled, the transaction hasn't
+// been reverted yet, and it is within the undo window.
+func (s *Settings) IsWithinUndoWindow(tx *Transaction, now time.Time) (bool, error) {
+	if !s.Payment.Reverse.IsEnabled || tx.IsReversed {
+		return false, nil
+	}
+	deadline, err := s.UndoDeadline(tx)
+	if err != nil {
+		return false, err
+	}
+	return now.Before(deadline), nil
+}
@@ -0,0 +1,82 @@
+package schema
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"5 minute", 5 * time.Minute, true},
+		{"5 minutes", 5 * time.Minute, true},
+		{"10 day", 10 * 24 * time.Hour, true},
+		{"+1 week", 7 * 24 * time.Hour, true},
+		{"1 day 12 hours", 36 * time.Hour, true},
+		{"30sec", 30 * time.Second, true},
+		{"2 Fortnights", 28 * 24 * time.Hour, true},
+		{"0 minute", 0, true},
+		{"", 0, false},
+		{"   ", 0, false},
+		{"5", 0, false},
+		{"minute", 0, false},
+		{"five minutes", 0, false},
+		{"1 month", 0, false},
+		{"1 year", 0, false},
+		{"1 day 12", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseInterval(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseInterval(%q): err = %v, want ok = %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseInterval(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsWithinUndoWindow(t *testing.T) {
+	created := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	settings := func(enabled bool, timeout string) *Settings {
+		s := &Settings{}
+		s.I18n.Timezone = "UTC"
+		s.Payment.Reverse.IsEnabled = enabled
+		s.Payment.Reverse.Timeout = timeout
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		settings *Settings
+		reversed bool
+		now      time.Time
+		want     bool
+		ok       bool
+	}{
+		{"just created", settings(true, "5 minute"), false, created, true, true},
+		{"before deadline", settings(true, "5 minute"), false, created.Add(5*time.Minute - time.Second), true, true},
+		{"at deadline", settings(true, "5 minute"), false, created.Add(5 * time.Minute), false, true},
+		{"after deadline", settings(true, "5 minute"), false, created.Add(time.Hour), false, true},
+		{"already reversed", settings(true, "5 minute"), true, created, false, true},
+		{"reverting disabled", settings(false, "5 minute"), false, created, false, true},
+		{"zero timeout", settings(true, "0 minute"), false, created, false, true},
+		{"invalid timeout", settings(true, "5 parsecs"), false, created, false, false},
+	}
+	for _, tt := range tests {
+		tx := &Transaction{TimeCreated: Timestamp(created), IsReversed: tt.reversed}
+		got, err := tt.settings.IsWithinUndoWindow(tx, tt.now)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok = %v", tt.name, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// An amount of money in the currency's minor unit, e.g. cents.
+// This is the unit of all amounts in the API, like User.Balance or
+// Article.Value, so those convert directly: Money(user.Balance).
+//
+// All arithmetic is exact integer arithmetic; there's no rounding.
+type Money int
+
+// Languages that use a decimal comma and put the currency symbol
+// after the amount. Anything else is formatted like English.
+var decimalCommaLanguages = map[string]bool{
+	"cs": true, "da": true, "de": true, "es": true, "fi": true,
+	"fr": true, "it": true, "nb": true, "nl": true, "pl": true,
+	"pt": true, "ru": true, "sv": true, "tr": true,
+}
+
+// Currency symbols that are stripped when parsing.
+var currencySymbols = []string{"€", "$", "£", "¥", "₣", "₽", "₺", "zł", "kr", "Fr."}
+
+// ISO 4217 codes that are stripped when parsing; see Settings.ParseMoney
+// for others.
+var currencyCodes = map[string]bool{
+	"AUD": true, "BGN": true, "BRL": true, "CAD": true, "CHF": true,
+	"CNY": true, "CZK": true, "DKK": true, "EUR": true, "GBP": true,
+	"HKD": true, "HUF": true, "ILS": true, "INR": true, "ISK": true,
+	"JPY": true, "KRW": true, "MXN": true, "NOK": true, "NZD": true,
+	"PLN": true, "RON": true, "RUB": true, "SEK": true, "SGD": true,
+	"TRY": true, "UAH": true, "USD": true, "ZAR": true,
+}
+
+// Parses user input like "1,50", "-2.5 €", "€3", or "1.234,56 EUR".
+//
+// Both decimal points and decimal commas are accepted, as are
+// thousands separators of the respective other kind; at most two
+// decimal places are allowed. A single separator followed by exactly
+// three digits, like "1.500", is rejected as ambiguous; write "1500",
+// "1.500,00", or "1.500.000" instead. Common currency symbols and
+// ISO 4217 codes before or after the amount are ignored.
+func ParseMoney(s string) (Money, error) {
+	return parseMoney(s)
+}
+
+// Like ParseMoney, but also ignores the server's currency symbol and
+// code, even if they are uncommon.
+func (s *Settings) ParseMoney(str string) (Money, error) {
+	return parseMoney(str, s.I18n.Currency.Symbol, s.I18n.Currency.Alpha3)
+}
+
+func parseMoney(s string, currencies ...string) (Money, error) {
+	invalid := fmt.Errorf("invalid amount of money: %q", s)
+
+	t := strings.TrimSpace(s)
+	negative := false
+
+	// signs and currencies may come in any order, e.g. "-€3" or "€-3"
+	for changed := true; changed; {
+		changed = false
+		t = strings.TrimFunc(t, unicode.IsSpace)
+		if strings.HasPrefix(t, "-") || strings.HasPrefix(t, "−") {
+			if negative {
+				return 0, invalid
+			}
+			negative = true
+			t = strings.TrimPrefix(strings.TrimPrefix(t, "-"), "−")
+			changed = true
+		} else if strings.HasPrefix(t, "+") {
+			t = t[1:]
+			changed = true
+		}
+		if u, ok := trimCurrency(t, currencies); ok {
+			t = u
+			changed = true
+		}
+	}
+
+	if t == "" {
+		return 0, invalid
+	}
+
+	units, cents, ok := splitDecimal(t)
+	if !ok {
+		return 0, invalid
+	}
+
+	u, err := strconv.Atoi(units)
+	if err != nil || u > int(^uint(0)>>1)/100-1 {
+		return 0, invalid
+	}
+	c, err := strconv.Atoi(cents)
+	if err != nil {
+		return 0, invalid
+	}
+
+	m := Money(u*100 + c)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// Strips a known currency symbol or code, or one of the extra ones,
+// from either end of s.
+func trimCurrency(s string, extra []string) (string, bool) {
+	for _, syms := range [][]string{extra, currencySymbols} {
+		for _, sym := range syms {
+			if sym == "" {
+				continue
+			}
+			if strings.HasPrefix(s, sym) {
+				return s[len(sym):], true
+			}
+			if strings.HasSuffix(s, sym) {
+				return s[:len(s)-len(sym)], true
+			}
+		}
+	}
+
+	if len(s) >= 3 && currencyCodes[s[:3]] {
+		return s[3:], true
+	}
+	if len(s) >= 3 && currencyCodes[s[len(s)-3:]] {
+		return s[:len(s)-3], true
+	}
+	return s, false
+}
+
+// Splits a number into its integer digits and two decimal digits,
+// removing thousands separators.
+func splitDecimal(s string) (string, string, bool) {
+	i := strings.LastIndexAny(s, ".,")
+	units, cents := s, "00"
+	var group byte
+
+	if i >= 0 {
+		switch len(s) - i - 1 {
+		case 1, 2:
+			units, cents = s[:i], s[i+1:]
+			if len(cents) == 1 {
+				cents += "0"
+			}
+			group = ',' // the other kind
+			if s[i] == ',' {
+				group = '.'
+			}
+		case 3:
+			// a thousands separator, unless it might be a decimal one
+			if strings.Count(s, s[i:i+1]) < 2 {
+				return "", "", false
+			}
+			group = s[i]
+		default:
+			return "", "", false
+		}
+	}
+
+	if units == "" {
+		units = "0"
+	}
+
+	// thousands separators must separate groups of three digits
+	if group != 0 && strings.IndexByte(units, group) >= 0 {
+		groups := strings.Split(units, string(group))
+		for j, g := range groups {
+			if (j == 0 && (len(g) < 1 || len(g) > 3)) || (j > 0 && len(g) != 3) {
+				return "", "", false
+			}
+		}
+		units = strings.Join(groups, "")
+	}
+
+	for _, digits := range []string{units, cents} {
+		for _, r := range digits {
+			if r < '0' || r > '9' {
+				return "", "", false
+			}
+		}
+	}
+	return units, cents, true
+}
+
+func (m Money) Add(o Money) Money {
+	return m + o
+}
+
+func (m Money) Sub(o Money) Money {
+	return m - o
+}
+
+func (m Money) Mul(n int) Money {
+	return m * Money(n)
+}
+
+func (m Money) Neg() Money {
+	return -m
+}
+
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Splits the amount into n parts that differ by at most one cent and
+// sum up to the amount exactly; parts of larger magnitude come first.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	parts := make([]Money, n)
+	q, r := m/Money(n), m%Money(n)
+	for i := range parts {
+		parts[i] = q
+		if r > 0 && Money(i) < r {
+			parts[i]++
+		} else if r < 0 && Money(i) < -r {
+			parts[i]--
+		}
+	}
+	return parts
+}
+
+// Formats the amount without currency, like "-1.50".
+func (m Money) String() string {
+	return m.format(".", "")
+}
+
+// Formats the amount with the currency, according to the conventions
+// of the language, like "€1,234.50" for "en" or "1.234,50 €" for "de".
+func (m Money) Format(c Currency, language string) string {
+	symbol := c.Symbol
+	if symbol == "" {
+		symbol = c.Alpha3
+	}
+	if symbol == "" {
+		symbol = c.Name
+	}
+
+	lang := strings.ToLower(language)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+
+	sign := ""
+	if m < 0 {
+		sign = "-"
+	}
+
+	if decimalCommaLanguages[lang] {
+		s := sign + m.Abs().format(",", ".")
+		if symbol == "" {
+			return s
+		}
+		return s + " " + symbol
+	}
+	return sign + symbol + m.Abs().format(".", ",")
+}
+
+func (m Money) format(decimal, group string) string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+
+	units := strconv.Itoa(int(m / 100))
+	if group != "" {
+		for i := len(units) - 3; i > 0; i -= 3 {
+			units = units[:i] + group + units[i:]
+		}
+	}
+	return fmt.Sprintf("%s%s%s%02d", sign, units, decimal, int(m%100))
+}
+
+// Formats the amount according to the server's currency and language.
+func (s *Settings) FormatMoney(m Money) string {
+	return m.Format(s.I18n.Currency, s.I18n.Language)
+}
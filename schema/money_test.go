@@ -0,0 +1,109 @@
+package schema
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		ok   bool
+	}{
+		{"0", 0, true},
+		{"3", 300, true},
+		{"1,50", 150, true},
+		{"1.50", 150, true},
+		{"1.5", 150, true},
+		{",5", 50, true},
+		{"-2.5 €", -250, true},
+		{"€3", 300, true},
+		{"-€3", -300, true},
+		{"€-3", -300, true},
+		{"+4", 400, true},
+		{"1.234,56 EUR", 123456, true},
+		{"1,234.56", 123456, true},
+		{"USD 12", 1200, true},
+		{"1.234.567", 123456700, true},
+		{"1,234,567", 123456700, true},
+		{"1500", 150000, true},
+		{"12 zł", 1200, true},
+
+		// a single separator with three digits might be either
+		{"1.500", 0, false},
+		{"1,500", 0, false},
+		{"-1.500 €", 0, false},
+		{"1,500.000", 0, false},
+
+		{"", 0, false},
+		{"€", 0, false},
+		{"--1", 0, false},
+		{"1.505", 0, false},
+		{"1.2345", 0, false},
+		{"12.34.56", 0, false},
+		{"1.23.456", 0, false},
+		{"1,2345.00", 0, false},
+		{"ABC12", 0, false},
+		{"12 XYZ", 0, false},
+		{"12eur", 0, false},
+		{"1e3", 0, false},
+		{"99999999999999999999", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseMoney(%q): err = %v, want ok = %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSettingsParseMoney(t *testing.T) {
+	s := &Settings{}
+	s.I18n.Currency.Alpha3 = "XAF"
+	s.I18n.Currency.Symbol = "FCFA"
+
+	tests := []struct {
+		in   string
+		want Money
+		ok   bool
+	}{
+		{"12 XAF", 1200, true},
+		{"FCFA 1,50", 150, true},
+		{"3 EUR", 300, true},
+		{"3 XYZ", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := s.ParseMoney(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseMoney(%q): err = %v, want ok = %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	eur := Currency{Symbol: "€", Alpha3: "EUR"}
+	tests := []struct {
+		m        Money
+		language string
+		want     string
+	}{
+		{123450, "en", "€1,234.50"},
+		{123450, "de", "1.234,50 €"},
+		{-5, "de-DE", "-0,05 €"},
+		{-150, "en_US", "-€1.50"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Format(eur, tt.language); got != tt.want {
+			t.Errorf("Money(%d).Format(%q) = %q, want %q", tt.m, tt.language, got, tt.want)
+		}
+	}
+	if got := Money(-150).String(); got != "-1.50" {
+		t.Errorf("Money(-150).String() = %q", got)
+	}
+}
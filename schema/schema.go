@@ -24,3 +24,22 @@ func (t *Timestamp) UnmarshalJSON(b []byte) error {
 	*t = Timestamp(pt)
 	return nil
 }
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if time.Time(t).IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + time.Time(t).Format(TimestampLayout) + `"`), nil
+}
+
+// Returns the timestamp's wall clock time in the given location.
+// The server sends timestamps without a timezone, in its own one;
+// see Settings.Location.
+func (t Timestamp) In(loc *time.Location) time.Time {
+	tt := time.Time(t)
+	if tt.IsZero() {
+		return tt
+	}
+	return time.Date(tt.Year(), tt.Month(), tt.Day(),
+		tt.Hour(), tt.Minute(), tt.Second(), tt.Nanosecond(), loc)
+}
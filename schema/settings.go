@@ -1,5 +1,10 @@
 package schema
 
+import (
+	"reflect"
+	"strings"
+)
+
 const EndpointSettings = "/settings"
 
 type Limit struct {
@@ -13,6 +18,12 @@ type AmountPreset struct {
 	PresetAmounts     []int `json:"steps"`
 }
 
+type Currency struct {
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+	Alpha3 string `json:"alpha3"`
+}
+
 type Settings struct {
 	Common struct {
 		IdleTimeout int `json:"idleTimeout"`
@@ -29,14 +40,10 @@ type Settings struct {
 	} `json:"user"`
 
 	I18n struct {
-		DateFormat string `json:"dateFormat"`
-		Timezone   string `json:"timezone"`
-		Language   string `json:"language"`
-		Currency   struct {
-			Name   string `json:"name"`
-			Symbol string `json:"symbol"`
-			Alpha3 string `json:"alpha3"`
-		} `json:"currency"`
+		DateFormat string   `json:"dateFormat"`
+		Timezone   string   `json:"timezone"`
+		Language   string   `json:"language"`
+		Currency   Currency `json:"currency"`
 	} `json:"i18n"`
 
 	Account struct {
@@ -61,3 +68,42 @@ type Settings struct {
 type SettingsResponse struct {
 	Settings Settings `json:"settings"`
 }
+
+// A SettingChange describes a setting that differs between two
+// versions of the Settings.
+type SettingChange struct {
+	Field string // path of Go field names, e.g. "Account.Limit.Upper"
+	Old   interface{}
+	New   interface{}
+}
+
+// Reports whether the change concerns the field or any of its
+// subfields, e.g. whether "Account.Limit.Upper" affects "Account.Limit".
+func (c SettingChange) Affects(field string) bool {
+	return c.Field == field || strings.HasPrefix(c.Field, field+".")
+}
+
+// Lists the settings that differ between old and new; returns nil if
+// there are no differences. Fields that aren't structs, like the list
+// of preset amounts, are compared as a whole.
+func DiffSettings(old, new *Settings) []SettingChange {
+	var changes []SettingChange
+	diffStruct("", reflect.ValueOf(*old), reflect.ValueOf(*new), &changes)
+	return changes
+}
+
+func diffStruct(prefix string, old, new reflect.Value, changes *[]SettingChange) {
+	for i := 0; i < old.NumField(); i++ {
+		name := prefix + old.Type().Field(i).Name
+		o, n := old.Field(i), new.Field(i)
+		if o.Kind() == reflect.Struct {
+			diffStruct(name+".", o, n, changes)
+		} else if !reflect.DeepEqual(o.Interface(), n.Interface()) {
+			*changes = append(*changes, SettingChange{
+				Field: name,
+				Old:   o.Interface(),
+				New:   n.Interface(),
+			})
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package strichliste
 
 import (
+	"context"
 	"github.com/jktr/go-strichliste/schema"
 	"net/http"
+	"sync"
+	"time"
 )
 
 // A SettingsClient carries the necessary context
@@ -14,10 +17,10 @@ type SettingsClient struct {
 // GET /settings
 //
 // Retrieves the current server settings.
-func (s *SettingsClient) Get() (*schema.Settings, *Response, error) {
+func (s *SettingsClient) Get(ctx context.Context) (*schema.Settings, *Response, error) {
 	path := schema.EndpointSettings
 
-	req, err := s.client.NewRequest(http.MethodGet, path, nil)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -30,3 +33,145 @@ func (s *SettingsClient) Get() (*schema.Settings, *Response, error) {
 
 	return &body.Settings, resp, nil
 }
+
+// The time for which cached settings are considered fresh,
+// unless configured otherwise via WithSettingsTTL.
+const DefaultSettingsTTL = 5 * time.Minute
+
+// Caches the server settings; see SettingsClient.Cached.
+type settingsCache struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	settings    *schema.Settings
+	fetched     time.Time
+	subscribers map[int]func([]schema.SettingChange)
+	nextID      int
+}
+
+// Configure for how long cached settings are considered fresh.
+// Not setting this option will default to DefaultSettingsTTL.
+func WithSettingsTTL(ttl time.Duration) ClientOption {
+	return func(client *Client) {
+		client.settingsCache.ttl = ttl
+	}
+}
+
+// GET /settings
+//
+// Retrieves the server settings from the cache, or from the server if
+// the cache is empty or older than its TTL; see WithSettingsTTL. The
+// returned settings are shared and must not be modified.
+func (s *SettingsClient) Cached(ctx context.Context) (*schema.Settings, error) {
+	c := &s.client.settingsCache
+
+	c.mu.Lock()
+	settings, fetched := c.settings, c.fetched
+	c.mu.Unlock()
+
+	if settings != nil && time.Since(fetched) < c.ttl {
+		return settings, nil
+	}
+	settings, _, err := s.Refresh(ctx)
+	return settings, err
+}
+
+// GET /settings
+//
+// Retrieves the current server settings and stores them in the cache.
+// Subscribers are notified of any changes to the previously cached
+// settings; see Subscribe.
+func (s *SettingsClient) Refresh(ctx context.Context) (*schema.Settings, *Response, error) {
+	settings, resp, err := s.Get(ctx)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	c := &s.client.settingsCache
+
+	c.mu.Lock()
+	old := c.settings
+	c.settings, c.fetched = settings, time.Now()
+	subscribers := make([]func([]schema.SettingChange), 0, len(c.subscribers))
+	for _, fn := range c.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	c.mu.Unlock()
+
+	if old != nil {
+		if changes := schema.DiffSettings(old, settings); changes != nil {
+			for _, fn := range subscribers {
+				fn(changes)
+			}
+		}
+	}
+	return settings, resp, nil
+}
+
+// Drops the cached settings, forcing the next call to Cached to
+// retrieve them from the server.
+func (s *SettingsClient) Invalidate() {
+	c := &s.client.settingsCache
+	c.mu.Lock()
+	c.fetched = time.Time{}
+	c.mu.Unlock()
+}
+
+// Registers a function that is called with the list of changes
+// whenever refreshing the cache yields settings that differ from the
+// previously cached ones. Returns a function that cancels the
+// subscription.
+//
+//	cancel := client.Settings.Subscribe(func(changes []schema.SettingChange) {
+//		for _, c := range changes {
+//			if c.Affects("Account.Limit") {
+//			}
+//		}
+//	})
+func (s *SettingsClient) Subscribe(fn func([]schema.SettingChange)) (cancel func()) {
+	c := &s.client.settingsCache
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.subscribers == nil {
+		c.subscribers = make(map[int]func([]schema.SettingChange))
+	}
+	id := c.nextID
+	c.nextID++
+	c.subscribers[id] = fn
+
+	return func() {
+		c.mu.Lock()
+		delete(c.subscribers, id)
+		c.mu.Unlock()
+	}
+}
+
+// Refreshes the cache in the background every interval, until the
+// context is done. Errors are passed to onError, which may be nil.
+func (s *SettingsClient) AutoRefresh(ctx context.Context, interval time.Duration, onError func(error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, _, err := s.Refresh(ctx); err != nil && onError != nil && ctx.Err() == nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+}
+
+// Creates a Validator based on the cached settings; see Cached.
+func (s *SettingsClient) Validator(ctx context.Context) (*Validator, error) {
+	settings, err := s.Cached(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewValidator(settings), nil
+}
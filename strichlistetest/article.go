@@ -0,0 +1,223 @@
+package strichlistetest
+
+import (
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"net/http"
+	"strings"
+)
+
+func (s *Server) routeArticle(r *http.Request, path []string) (interface{}, *apiError) {
+	switch {
+	case len(path) == 0 && r.Method == http.MethodGet:
+		return s.listArticles(r)
+	case len(path) == 0 && r.Method == http.MethodPost:
+		return s.createArticle(r)
+	case len(path) == 1 && path[0] == "search" && r.Method == http.MethodGet:
+		return s.searchArticles(r)
+	case len(path) != 1:
+		return nil, errNotFound()
+	}
+
+	a, err := s.lookupArticle(path[0])
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		return schema.SingleArticleResponse{Article: s.articleJSON(a)}, nil
+	case http.MethodPost:
+		return s.updateArticle(r, a)
+	case http.MethodDelete:
+		a.IsActive = false
+		return schema.SingleArticleResponse{Article: s.articleJSON(a)}, nil
+	}
+	return nil, errNotFound()
+}
+
+func (s *Server) lookupArticle(segment string) (*article, *apiError) {
+	id, ok := parseID(segment)
+	if !ok {
+		return nil, errParameterInvalid("articleId")
+	}
+	a := s.articleByID(id)
+	if a == nil {
+		return nil, errArticleNotFound(id)
+	}
+	return a, nil
+}
+
+func (s *Server) articleByID(id int) *article {
+	if id < 1 || id > len(s.articles) {
+		return nil
+	}
+	return s.articles[id-1]
+}
+
+// Returns the active article with the barcode, if any.
+func (s *Server) articleByBarcode(barcode string) *article {
+	for _, a := range s.articles {
+		if a.IsActive && a.Barcode != nil && *a.Barcode == barcode {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) newArticle(name string, value int, barcode string, precursor *article) *article {
+	a := &article{
+		Article: schema.Article{
+			ID:          len(s.articles) + 1,
+			Name:        name,
+			Value:       value,
+			IsActive:    true,
+			TimeCreated: schema.Timestamp(s.now()),
+		},
+		precursor: precursor,
+	}
+	if barcode != "" {
+		a.Barcode = &barcode
+	}
+	s.articles = append(s.articles, a)
+	return a
+}
+
+// Renders an article, including its direct precursor. Like the
+// server, the precursor's own precursor is omitted.
+func (s *Server) articleJSON(a *article) schema.Article {
+	article := a.Article
+	if a.precursor != nil {
+		precursor := a.precursor.Article
+		article.Precursor = &precursor
+	}
+	return article
+}
+
+func (s *Server) articlesJSON(r *http.Request, articles []*article) schema.MultiArticleResponse {
+	from, to := paginate(r, len(articles))
+	resp := schema.MultiArticleResponse{Articles: make([]schema.Article, 0, to-from)}
+	for _, a := range articles[from:to] {
+		resp.Articles = append(resp.Articles, s.articleJSON(a))
+	}
+	return resp
+}
+
+// Reports whether any transaction references the article.
+func (s *Server) isArticleUsed(a *article) bool {
+	for _, tx := range s.transactions {
+		if tx.article == a {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) listArticles(r *http.Request) (interface{}, *apiError) {
+	return s.articlesJSON(r, s.articles), nil
+}
+
+// Searches articles by name (query parameter "query") or barcode
+// (query parameter "barcode"); both match partially, and both active
+// and inactive articles are returned.
+func (s *Server) searchArticles(r *http.Request) (interface{}, *apiError) {
+	q := r.URL.Query()
+	query := strings.ToLower(q.Get("query"))
+	barcode := q.Get("barcode")
+
+	var matches []*article
+	for _, a := range s.articles {
+		if q.Get("barcode") != "" {
+			if a.Barcode != nil && strings.Contains(*a.Barcode, barcode) {
+				matches = append(matches, a)
+			}
+		} else if strings.Contains(strings.ToLower(a.Name), query) {
+			matches = append(matches, a)
+		}
+	}
+	return s.articlesJSON(r, matches), nil
+}
+
+type articleRequest struct {
+	Name    *string `json:"name"`
+	Value   *int    `json:"amount"`
+	Barcode *string `json:"barcode"`
+}
+
+// Validates an article request; except is an article whose barcode
+// may be reused.
+func (s *Server) validateArticle(req *articleRequest, except *article) *apiError {
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		return errParameterMissing("name")
+	}
+	if req.Value == nil {
+		return errParameterMissing("amount")
+	}
+	if req.Barcode != nil && *req.Barcode != "" {
+		if other := s.articleByBarcode(*req.Barcode); other != nil && other != except {
+			return &apiError{schema.ErrorArticleBarcodeAlreadyExists, 409,
+				fmt.Sprintf("Article with barcode '%s' already exists", *req.Barcode)}
+		}
+	}
+	return nil
+}
+
+func (s *Server) createArticle(r *http.Request) (interface{}, *apiError) {
+	var req articleRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if err := s.validateArticle(&req, nil); err != nil {
+		return nil, err
+	}
+
+	barcode := ""
+	if req.Barcode != nil {
+		barcode = *req.Barcode
+	}
+	a := s.newArticle(strings.TrimSpace(*req.Name), *req.Value, barcode, nil)
+	return schema.SingleArticleResponse{Article: s.articleJSON(a)}, nil
+}
+
+// Updates an article in place, unless transactions already reference
+// it; in that case, the article is deactivated and replaced by a new
+// one that references it as its precursor.
+func (s *Server) updateArticle(r *http.Request, a *article) (interface{}, *apiError) {
+	var req articleRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if !a.IsActive {
+		return nil, errArticleInactive(a.ID)
+	}
+	if err := s.validateArticle(&req, a); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(*req.Name)
+	barcode := ""
+	if req.Barcode != nil {
+		barcode = *req.Barcode
+	}
+
+	if s.isArticleUsed(a) {
+		a.IsActive = false
+		a = s.newArticle(name, *req.Value, barcode, a)
+	} else {
+		a.Name = name
+		a.Value = *req.Value
+		a.Barcode = nil
+		if barcode != "" {
+			a.Barcode = &barcode
+		}
+	}
+	return schema.SingleArticleResponse{Article: s.articleJSON(a)}, nil
+}
+
+func errArticleNotFound(id int) *apiError {
+	return &apiError{schema.ErrorArticleNotFound, 404, fmt.Sprintf("Article '%d' not found", id)}
+}
+
+func errArticleInactive(id int) *apiError {
+	return &apiError{schema.ErrorArticleInactive, 400, fmt.Sprintf("Article '%d' is inactive", id)}
+}
@@ -0,0 +1,91 @@
+package strichlistetest
+
+import (
+	"github.com/jktr/go-strichliste/schema"
+	"time"
+)
+
+// The number of days covered by the system metrics.
+const metricsDays = 30
+
+func (s *Server) userMetrics(u *user) schema.UserMetrics {
+	var m schema.UserMetrics
+	m.Balance = u.Balance
+
+	articles := make(map[*article]*schema.ArticleMetric)
+	var order []*article
+
+	for _, tx := range s.transactions {
+		if tx.user != u || tx.deleted {
+			continue
+		}
+
+		m.Transactions.Count++
+		if tx.amount < 0 {
+			m.Transactions.Outgoing.Count++
+			m.Transactions.Outgoing.Cashflow -= tx.amount
+		} else {
+			m.Transactions.Incoming.Count++
+			m.Transactions.Incoming.Cashflow += tx.amount
+		}
+
+		if tx.article == nil {
+			continue
+		}
+		am, ok := articles[tx.article]
+		if !ok {
+			am = &schema.ArticleMetric{Article: s.articleJSON(tx.article)}
+			articles[tx.article] = am
+			order = append(order, tx.article)
+		}
+		am.Count += *tx.quantity
+		am.Spent -= tx.amount
+	}
+
+	m.Articles = make([]schema.ArticleMetric, 0, len(order))
+	for _, a := range order {
+		m.Articles = append(m.Articles, *articles[a])
+	}
+	return m
+}
+
+func (s *Server) systemMetrics() schema.SystemMetrics {
+	var m schema.SystemMetrics
+
+	m.Users = len(s.users)
+	for _, u := range s.users {
+		m.Balance += u.Balance
+	}
+
+	today := s.now().Truncate(24 * time.Hour)
+	m.Days = make([]schema.DayMetric, metricsDays)
+	distinct := make([]map[*user]bool, metricsDays)
+	for i := range m.Days {
+		m.Days[i].Date = today.AddDate(0, 0, i-metricsDays+1).Format("2006-01-02")
+		distinct[i] = make(map[*user]bool)
+	}
+
+	for _, tx := range s.transactions {
+		if tx.deleted {
+			continue
+		}
+		m.Transactions++
+
+		i := metricsDays - 1 - int(today.Sub(tx.created.Truncate(24*time.Hour))/(24*time.Hour))
+		if i < 0 || i >= metricsDays {
+			continue
+		}
+		day := &m.Days[i]
+		day.Transactions++
+		day.Balance += tx.amount
+		if tx.amount > 0 {
+			day.IncomingCashflow += tx.amount
+		} else {
+			day.OutgoingCashflow += tx.amount
+		}
+		distinct[i][tx.user] = true
+		day.DistinctUsers = len(distinct[i])
+	}
+
+	return m
+}
@@ -0,0 +1,289 @@
+// Package strichlistetest provides an in-memory fake strichliste
+// server for testing code that uses the strichliste client.
+//
+//	srv := strichlistetest.NewServer()
+//	defer srv.Close()
+//
+//	alice := srv.AddUser("alice", 500)
+//	client := srv.NewClient()
+//	tx, _, err := client.Transaction.Context(alice.ID).Delta(ctx, -150)
+//
+// The fake implements the whole API with the server's semantics:
+// balance and transaction boundaries from the settings, article
+// versioning via precursors, reversible transactions within the undo
+// window, and the error classes of schema/error.go. State is lost when
+// the server is closed.
+package strichlistetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The path under which the fake serves the API.
+const PathPrefix = "/api"
+
+type (
+	// A Server is a fake strichliste server listening on a local
+	// loopback address.
+	Server struct {
+		*httptest.Server
+
+		mu           sync.Mutex
+		clock        func() time.Time // see SetNow
+		settings     schema.Settings
+		users        []*user
+		articles     []*article
+		transactions []*transaction
+	}
+
+	user struct {
+		schema.User
+	}
+
+	article struct {
+		schema.Article
+		precursor *article
+	}
+
+	transaction struct {
+		id        int
+		user      *user
+		amount    int
+		comment   string
+		created   time.Time
+		deleted   bool
+		sender    *user
+		recipient *user
+		quantity  *int
+		article   *article
+		linked    *transaction // counterpart of a transfer
+	}
+
+	// An API error, as produced by the server.
+	apiError struct {
+		class   schema.ErrorClass
+		code    int
+		message string
+	}
+)
+
+// Returns the settings a new Server starts out with; these mirror the
+// defaults of the strichliste server.
+func DefaultSettings() schema.Settings {
+	var s schema.Settings
+	s.Common.IdleTimeout = 30000
+	s.User.StalePeriod = "10 day"
+	s.I18n.DateFormat = "YYYY-MM-DD HH:mm:ss"
+	s.I18n.Timezone = "UTC"
+	s.I18n.Language = "en"
+	s.I18n.Currency.Name = "Euro"
+	s.I18n.Currency.Symbol = "€"
+	s.I18n.Currency.Alpha3 = "EUR"
+	s.Account.Limit = schema.Limit{Lower: -20000, Upper: 20000}
+	s.Payment.Reverse.IsEnabled = true
+	s.Payment.Reverse.Deletes = false
+	s.Payment.Reverse.Timeout = "5 minute"
+	s.Payment.Limit = schema.Limit{Lower: -2000, Upper: 15000}
+	s.Payment.TransferFunds.IsEnabled = true
+	s.Payment.Deposit = schema.AmountPreset{
+		IsEnabled:         true,
+		AllowCustomAmount: true,
+		PresetAmounts:     []int{50, 100, 200, 500, 1000},
+	}
+	s.Payment.Withdraw = schema.AmountPreset{
+		IsEnabled:         true,
+		AllowCustomAmount: true,
+		PresetAmounts:     []int{50, 100, 200, 500, 1000},
+	}
+	return s
+}
+
+// Starts a new fake server with DefaultSettings and no data.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		clock:    time.Now,
+		settings: DefaultSettings(),
+	}
+	s.Server = httptest.NewServer(http.StripPrefix(PathPrefix, http.HandlerFunc(s.serveHTTP)))
+	return s
+}
+
+// Returns the API endpoint of the server, suitable for WithEndpoint.
+func (s *Server) Endpoint() string {
+	return s.URL + PathPrefix
+}
+
+// Creates a client for the server. Options are applied after
+// configuring the endpoint.
+func (s *Server) NewClient(options ...strichliste.ClientOption) *strichliste.Client {
+	options = append([]strichliste.ClientOption{strichliste.WithEndpoint(s.Endpoint())}, options...)
+	return strichliste.NewClient(options...)
+}
+
+// Returns a copy of the current settings.
+func (s *Server) Settings() schema.Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings
+}
+
+// Modifies the settings.
+func (s *Server) UpdateSettings(update func(*schema.Settings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&s.settings)
+}
+
+// Adds an active user with the given balance, bypassing the API.
+// Panics if a user with that name already exists.
+func (s *Server) AddUser(name string, balance int) schema.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userByName(name) != nil {
+		panic("strichlistetest: user already exists: " + name)
+	}
+	u := s.newUser(name)
+	u.Balance = balance
+	return u.User
+}
+
+// Adds an active article, bypassing the API. The barcode may be empty.
+func (s *Server) AddArticle(name string, value int, barcode string) schema.Article {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.newArticle(name, value, barcode, nil)
+	return s.articleJSON(a)
+}
+
+// Replaces the clock that the server uses for timestamps and the undo
+// window, e.g. to let the window expire without waiting; nil restores
+// time.Now. It is safe to call this while requests are being served.
+//
+//	start := time.Now()
+//	srv.SetNow(func() time.Time { return start.Add(10 * time.Minute) })
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now == nil {
+		now = time.Now
+	}
+	s.clock = now
+}
+
+// Must be called with mu held.
+func (s *Server) now() time.Time {
+	return s.clock().UTC().Truncate(time.Second)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	var body interface{}
+	var err *apiError
+	switch path[0] {
+	case "user":
+		body, err = s.routeUser(r, path[1:])
+	case "article":
+		body, err = s.routeArticle(r, path[1:])
+	case "transaction":
+		if len(path) == 1 && r.Method == http.MethodGet {
+			body, err = s.listTransactions(r, nil)
+		} else {
+			err = errNotFound()
+		}
+	case "settings":
+		if len(path) == 1 && r.Method == http.MethodGet {
+			body = schema.SettingsResponse{Settings: s.settings}
+		} else {
+			err = errNotFound()
+		}
+	case "metrics":
+		if len(path) == 1 && r.Method == http.MethodGet {
+			body = s.systemMetrics()
+		} else {
+			err = errNotFound()
+		}
+	default:
+		err = errNotFound()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		body = err.json()
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+// Decodes a JSON request body.
+func decode(r *http.Request, v interface{}) *apiError {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return &apiError{schema.ErrorParameterInvalid, 400, "Request body is invalid: " + err.Error()}
+	}
+	return nil
+}
+
+// Parses an ID from a path segment.
+func parseID(segment string) (int, bool) {
+	id, err := strconv.Atoi(segment)
+	return id, err == nil
+}
+
+// Applies limit/offset query parameters to a number of items, like
+// the server does; it ignores page numbers. Returns the bounds of the
+// requested slice.
+func paginate(r *http.Request, n int) (int, int) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	from, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 {
+		return 0, n
+	}
+	if from < 0 {
+		from = 0
+	}
+	if from > n {
+		from = n
+	}
+	to := from + limit
+	if to > n {
+		to = n
+	}
+	return from, to
+}
+
+func (e *apiError) json() interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"class":   "App\\Exception\\" + string(e.class),
+			"code":    e.code,
+			"message": e.message,
+		},
+	}
+}
+
+func errNotFound() *apiError {
+	return &apiError{"NotFoundHttpException", 404, "No route found"}
+}
+
+func errParameterMissing(name string) *apiError {
+	return &apiError{schema.ErrorParameterMissing, 400, fmt.Sprintf("Parameter '%s' is missing", name)}
+}
+
+func errParameterInvalid(name string) *apiError {
+	return &apiError{schema.ErrorParameterInvalid, 400, fmt.Sprintf("Parameter '%s' is invalid", name)}
+}
@@ -0,0 +1,377 @@
+package strichlistetest
+
+import (
+	"context"
+	"errors"
+	"github.com/jktr/go-strichliste"
+	"github.com/jktr/go-strichliste/schema"
+	"testing"
+	"time"
+)
+
+func TestTransactionBoundaries(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance int
+		amount  int
+		update  func(*schema.Settings)
+		want    error
+	}{
+		{"deposit", 0, 1500, nil, nil},
+		{"withdrawal", 0, -500, nil, nil},
+		{"zero", 0, 0, nil, schema.ErrorParameterInvalid},
+		{"upper transaction boundary", 0, 15000, nil, nil},
+		{"above transaction boundary", 0, 15001, nil, schema.ErrorTransactionBoundary},
+		{"lower transaction boundary", 0, -2000, nil, nil},
+		{"below transaction boundary", 0, -2001, nil, schema.ErrorTransactionBoundary},
+		{"upper account boundary", 19000, 1000, nil, nil},
+		{"above account boundary", 19000, 1001, nil, schema.ErrorAccountBalanceBoundary},
+		{"below account boundary", -19000, -1001, nil, schema.ErrorAccountBalanceBoundary},
+		{"back towards account boundary", -25000, 100, nil, nil},
+		{"further beyond account boundary", -25000, -100, nil, schema.ErrorAccountBalanceBoundary},
+		{"custom transaction boundary", 0, 600, func(s *schema.Settings) {
+			s.Payment.Limit = schema.Limit{Lower: -500, Upper: 500}
+		}, schema.ErrorTransactionBoundary},
+		{"custom account boundary", 0, -100, func(s *schema.Settings) {
+			s.Account.Limit = schema.Limit{Lower: 0, Upper: 1000}
+		}, schema.ErrorAccountBalanceBoundary},
+	}
+	for _, tt := range tests {
+		srv := NewServer()
+		if tt.update != nil {
+			srv.UpdateSettings(tt.update)
+		}
+		client := srv.NewClient()
+		u := srv.AddUser("alice", tt.balance)
+
+		tx, _, err := client.Transaction.Context(u.ID).Delta(context.Background(), tt.amount)
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+
+		wantBalance := tt.balance
+		if err == nil {
+			wantBalance += tt.amount
+			if tx.Value != tt.amount || !tx.IsReversible {
+				t.Errorf("%s: transaction %+v", tt.name, tx)
+			}
+		}
+		if got, _, _ := client.User.Get(context.Background(), u.ID); got.Balance != wantBalance {
+			t.Errorf("%s: balance = %d, want %d", tt.name, got.Balance, wantBalance)
+		}
+		srv.Close()
+	}
+}
+
+func TestTransfers(t *testing.T) {
+	tests := []struct {
+		name      string
+		sender    int
+		recipient int
+		amount    int
+		self      bool
+		disabled  bool
+		want      error
+	}{
+		// amounts apply to the sender, so sending is negative
+		{"transfer", 1000, 0, -500, false, false, nil},
+		{"recipient above account boundary", 0, 19800, -500, false, false, schema.ErrorAccountBalanceBoundary},
+		{"sender below account boundary", -19800, 0, -500, false, false, schema.ErrorAccountBalanceBoundary},
+		{"to self", 1000, 0, -500, true, false, schema.ErrorParameterInvalid},
+		{"disabled", 1000, 0, -500, false, true, schema.ErrorParameterInvalid},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		srv := NewServer()
+		srv.UpdateSettings(func(s *schema.Settings) { s.Payment.TransferFunds.IsEnabled = !tt.disabled })
+		client := srv.NewClient()
+		alice := srv.AddUser("alice", tt.sender)
+		bob := srv.AddUser("bob", tt.recipient)
+		if tt.self {
+			bob = alice
+		}
+
+		_, _, err := client.Transaction.Context(alice.ID).TransferFunds(ctx, bob.ID, tt.amount)
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if err == nil {
+			a, _, _ := client.User.Get(ctx, alice.ID)
+			b, _, _ := client.User.Get(ctx, bob.ID)
+			if a.Balance != tt.sender+tt.amount || b.Balance != tt.recipient-tt.amount {
+				t.Errorf("%s: balances %d and %d", tt.name, a.Balance, b.Balance)
+			}
+			txs, _, _ := client.Transaction.Context(bob.ID).List(ctx, nil)
+			if len(txs) != 1 || txs[0].From == nil || txs[0].From.ID != alice.ID || txs[0].Value != -tt.amount {
+				t.Errorf("%s: recipient's transactions %+v", tt.name, txs)
+			}
+		}
+		srv.Close()
+	}
+}
+
+func TestArticleVersions(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.NewClient()
+
+	alice := srv.AddUser("alice", 1000)
+	mate := srv.AddArticle("Club Mate", 150, "4029764001807")
+	coffee := srv.AddArticle("Coffee", 80, "")
+
+	// unused articles are updated in place
+	a, _, err := client.Article.Update(ctx, coffee.ID, &schema.ArticleUpdateRequest{Name: "Coffee", Value: 90})
+	if err != nil || a.ID != coffee.ID || a.Value != 90 || a.Precursor != nil {
+		t.Fatalf("updating an unused article: %+v, %v", a, err)
+	}
+
+	// used ones are replaced by a new version
+	if _, _, err := client.Transaction.Context(alice.ID).Purchase(ctx, mate.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	v2, _, err := client.Article.Update(ctx, mate.ID, &schema.ArticleUpdateRequest{Name: "Club Mate", Value: 170, Barcode: "4029764001807"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2.ID == mate.ID || v2.Precursor == nil || v2.Precursor.ID != mate.ID || !v2.IsActive {
+		t.Errorf("new version %+v", v2)
+	}
+	if v1, _, _ := client.Article.Get(ctx, mate.ID); v1.IsActive {
+		t.Errorf("old version still active")
+	}
+
+	if _, _, err := client.Transaction.Context(alice.ID).Purchase(ctx, v2.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	v3, _, err := client.Article.Update(ctx, v2.ID, &schema.ArticleUpdateRequest{Name: "Mate", Value: 180})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v3.Precursor == nil || v3.Precursor.ID != v2.ID || v3.Precursor.Precursor != nil {
+		t.Errorf("only the direct precursor should be included: %+v", v3.Precursor)
+	}
+
+	history, _, err := client.Article.History(ctx, mate.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, h := range history {
+		ids = append(ids, h.ID)
+	}
+	if len(ids) != 3 || ids[0] != mate.ID || ids[1] != v2.ID || ids[2] != v3.ID {
+		t.Errorf("history = %v", ids)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"purchase old version", func() error {
+			_, _, err := client.Transaction.Context(alice.ID).Purchase(ctx, mate.ID, 1)
+			return err
+		}, schema.ErrorArticleInactive},
+		{"update old version", func() error {
+			_, _, err := client.Article.Update(ctx, mate.ID, &schema.ArticleUpdateRequest{Name: "Club Mate", Value: 1})
+			return err
+		}, schema.ErrorArticleInactive},
+		{"barcode of old version", func() error {
+			_, _, err := client.Article.Create(ctx, &schema.ArticleCreateRequest{Name: "Mate clone", Value: 1, Barcode: "4029764001807"})
+			return err
+		}, nil},
+		{"barcode of active article", func() error {
+			_, _, err := client.Article.Create(ctx, &schema.ArticleCreateRequest{Name: "Mate clone 2", Value: 1, Barcode: "4029764001807"})
+			return err
+		}, schema.ErrorArticleBarcodeAlreadyExists},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestUndoWindow(t *testing.T) {
+	start := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		after   time.Duration
+		update  func(*schema.Settings)
+		want    error
+		visible bool // whether the reverted transaction is still listed
+	}{
+		{"immediately", 0, nil, nil, true},
+		{"just before the timeout", 5*time.Minute - time.Second, nil, nil, true},
+		{"at the timeout", 5 * time.Minute, nil, schema.ErrorTransactionNotDeletable, true},
+		{"after the timeout", time.Hour, nil, schema.ErrorTransactionNotDeletable, true},
+		{"longer timeout", time.Hour, func(s *schema.Settings) { s.Payment.Reverse.Timeout = "1 day" }, nil, true},
+		{"disabled", 0, func(s *schema.Settings) { s.Payment.Reverse.IsEnabled = false }, schema.ErrorTransactionNotDeletable, true},
+		{"deleting", 0, func(s *schema.Settings) { s.Payment.Reverse.Deletes = true }, nil, false},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		srv := NewServer()
+		srv.SetNow(func() time.Time { return start })
+		if tt.update != nil {
+			srv.UpdateSettings(tt.update)
+		}
+		client := srv.NewClient()
+		u := srv.AddUser("alice", 0)
+		tc := client.Transaction.Context(u.ID)
+
+		tx, _, err := tc.Delta(ctx, 500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.SetNow(func() time.Time { return start.Add(tt.after) })
+
+		got, _, _ := tc.Get(ctx, tx.ID)
+		if got.IsReversible != (tt.want == nil) {
+			t.Errorf("%s: IsReversible = %v", tt.name, got.IsReversible)
+		}
+
+		reverted, _, err := tc.Revert(ctx, tx.ID)
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+			srv.Close()
+			continue
+		}
+		if err != nil {
+			srv.Close()
+			continue
+		}
+
+		if !reverted.IsReversed || reverted.IsReversible {
+			t.Errorf("%s: reverted transaction %+v", tt.name, reverted)
+		}
+		if b, _, _ := client.User.Get(ctx, u.ID); b.Balance != 0 {
+			t.Errorf("%s: balance = %d after reverting", tt.name, b.Balance)
+		}
+		if _, _, err := tc.Revert(ctx, tx.ID); err == nil {
+			t.Errorf("%s: reverted twice", tt.name)
+		}
+		_, _, err = tc.Get(ctx, tx.ID)
+		if visible := err == nil; visible != tt.visible {
+			t.Errorf("%s: visible = %v (%v)", tt.name, visible, err)
+		}
+		if !tt.visible && !strichliste.IsNotFound(err) {
+			t.Errorf("%s: err = %v, want not found", tt.name, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.NewClient()
+
+	alice := srv.AddUser("alice", 0)
+	tc := client.Transaction.Context(alice.ID)
+
+	tests := []struct {
+		name     string
+		call     func() error
+		want     schema.ErrorClass
+		notFound bool
+	}{
+		{"unknown user", func() error {
+			_, _, err := client.User.Get(ctx, 42)
+			return err
+		}, schema.ErrorUserNotFound, true},
+		{"unknown user name", func() error {
+			_, _, err := client.User.GetByName(ctx, "bob")
+			return err
+		}, schema.ErrorUserNotFound, true},
+		{"existing user", func() error {
+			_, _, err := client.User.Create(ctx, &schema.UserCreateRequest{Name: "alice"})
+			return err
+		}, schema.ErrorUserAlreadyExists, false},
+		{"unknown article", func() error {
+			_, _, err := client.Article.Get(ctx, 42)
+			return err
+		}, schema.ErrorArticleNotFound, true},
+		{"purchase of unknown article", func() error {
+			_, _, err := tc.Purchase(ctx, 42, 1)
+			return err
+		}, schema.ErrorArticleNotFound, true},
+		{"unknown transaction", func() error {
+			_, _, err := tc.Get(ctx, 42)
+			return err
+		}, schema.ErrorTransactionNotFound, true},
+		{"article without name", func() error {
+			_, _, err := client.Article.Create(ctx, &schema.ArticleCreateRequest{Value: 100})
+			return err
+		}, schema.ErrorParameterMissing, false},
+		{"transfer to unknown user", func() error {
+			_, _, err := tc.TransferFunds(ctx, 42, 100)
+			return err
+		}, schema.ErrorUserNotFound, true},
+	}
+	for _, tt := range tests {
+		err := tt.call()
+		if got := strichliste.ErrorClassOf(err); got != tt.want {
+			t.Errorf("%s: class %q, want %q (%v)", tt.name, got, tt.want, err)
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: errors.Is(%v, %s) = false", tt.name, err, tt.want)
+		}
+		if got := strichliste.IsNotFound(err); got != tt.notFound {
+			t.Errorf("%s: IsNotFound = %v, want %v", tt.name, got, tt.notFound)
+		}
+	}
+}
+
+func TestSetNowConcurrently(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.NewClient()
+	u := srv.AddUser("alice", 0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			at := time.Now().Add(time.Duration(i) * time.Minute)
+			srv.SetNow(func() time.Time { return at })
+		}
+		srv.SetNow(nil)
+	}()
+	for i := 0; i < 10; i++ {
+		if _, _, err := client.Transaction.Context(u.ID).Delta(context.Background(), 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func TestListAllPages(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	client := s.NewClient()
+
+	u := s.AddUser("alice", 0)
+	n := 2*strichliste.DefaultPerPage + 50
+	for i := 0; i < n; i++ {
+		if _, _, err := client.Transaction.Context(u.ID).Delta(ctx, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	txs, _, err := client.Transaction.ListAll(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int]bool)
+	for _, tx := range txs {
+		seen[tx.ID] = true
+	}
+	if len(txs) != n || len(seen) != n {
+		t.Errorf("got %d transactions, %d distinct, want %d", len(txs), len(seen), n)
+	}
+}
@@ -0,0 +1,251 @@
+package strichlistetest
+
+import (
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (s *Server) routeUserTransaction(r *http.Request, u *user, path []string) (interface{}, *apiError) {
+	switch {
+	case len(path) == 0 && r.Method == http.MethodGet:
+		return s.listTransactions(r, u)
+	case len(path) == 0 && r.Method == http.MethodPost:
+		return s.createTransaction(r, u)
+	case len(path) != 1:
+		return nil, errNotFound()
+	}
+
+	tx, err := s.lookupTransaction(u, path[0])
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		return schema.SingleTransactionResponse{Transaction: s.transactionJSON(tx)}, nil
+	case http.MethodDelete:
+		return s.revertTransaction(tx)
+	}
+	return nil, errNotFound()
+}
+
+// Finds a visible transaction of a user by ID.
+func (s *Server) lookupTransaction(u *user, segment string) (*transaction, *apiError) {
+	id, ok := parseID(segment)
+	if !ok {
+		return nil, errParameterInvalid("transactionId")
+	}
+	if id >= 1 && id <= len(s.transactions) {
+		tx := s.transactions[id-1]
+		if tx.user == u && s.isVisible(tx) {
+			return tx, nil
+		}
+	}
+	return nil, &apiError{schema.ErrorTransactionNotFound, 404, fmt.Sprintf("Transaction '%d' not found", id)}
+}
+
+// Reports whether a transaction shows up in the API; reverted
+// transactions disappear if the settings say that reverting deletes.
+func (s *Server) isVisible(tx *transaction) bool {
+	return !tx.deleted || !s.settings.Payment.Reverse.Deletes
+}
+
+// Reports whether a transaction can still be reverted.
+func (s *Server) isDeletable(tx *transaction) bool {
+	reverse := s.settings.Payment.Reverse
+	if !reverse.IsEnabled || tx.deleted {
+		return false
+	}
+	timeout, err := schema.ParseInterval(reverse.Timeout)
+	if err != nil {
+		return false
+	}
+	return s.now().Before(tx.created.Add(timeout))
+}
+
+func (s *Server) transactionJSON(tx *transaction) schema.Transaction {
+	t := schema.Transaction{
+		ID:           tx.id,
+		Issuer:       tx.user.User,
+		Value:        tx.amount,
+		Comment:      tx.comment,
+		TimeCreated:  schema.Timestamp(tx.created),
+		IsReversed:   tx.deleted,
+		IsReversible: s.isDeletable(tx),
+		Quantity:     tx.quantity,
+	}
+	if tx.sender != nil {
+		sender := tx.sender.User
+		t.From = &sender
+	}
+	if tx.recipient != nil {
+		recipient := tx.recipient.User
+		t.To = &recipient
+	}
+	if tx.article != nil {
+		article := s.articleJSON(tx.article)
+		t.Article = &article
+	}
+	return t
+}
+
+// Lists the visible transactions of a user, or of all users if u is
+// nil, most recent first.
+func (s *Server) listTransactions(r *http.Request, u *user) (interface{}, *apiError) {
+	var matches []*transaction
+	for i := len(s.transactions) - 1; i >= 0; i-- {
+		tx := s.transactions[i]
+		if (u == nil || tx.user == u) && s.isVisible(tx) {
+			matches = append(matches, tx)
+		}
+	}
+
+	from, to := paginate(r, len(matches))
+	resp := schema.MultiTransactionResponse{Transactions: make([]schema.Transaction, 0, to-from)}
+	for _, tx := range matches[from:to] {
+		resp.Transactions = append(resp.Transactions, s.transactionJSON(tx))
+	}
+	return resp, nil
+}
+
+func (s *Server) newTransaction(u *user, amount int, comment string) *transaction {
+	tx := &transaction{
+		id:      len(s.transactions) + 1,
+		user:    u,
+		amount:  amount,
+		comment: comment,
+		created: s.now(),
+	}
+	s.transactions = append(s.transactions, tx)
+	u.Balance += amount
+	s.touch(u)
+	return tx
+}
+
+// Creates a transaction. The amount is applied to the user's balance;
+// for transfers, the recipient receives the negated amount. When
+// purchasing articles, the amount defaults to the negated value of the
+// articles, but may be overridden.
+func (s *Server) createTransaction(r *http.Request, u *user) (interface{}, *apiError) {
+	var req struct {
+		Amount    *int    `json:"amount"`
+		Comment   *string `json:"comment"`
+		Recipient *int    `json:"recipientId"`
+		Quantity  *int    `json:"quantity"`
+		ArticleID *int    `json:"articleId"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	var amount int
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+
+	var a *article
+	var quantity *int
+	if req.ArticleID != nil {
+		if a = s.articleByID(*req.ArticleID); a == nil {
+			return nil, errArticleNotFound(*req.ArticleID)
+		}
+		if !a.IsActive {
+			return nil, errArticleInactive(a.ID)
+		}
+		q := 1
+		if req.Quantity != nil {
+			q = *req.Quantity
+		}
+		if q < 1 {
+			return nil, errParameterInvalid("quantity")
+		}
+		quantity = &q
+		if amount == 0 {
+			amount = -a.Value * q
+		}
+	} else if req.Amount == nil {
+		return nil, errParameterMissing("amount")
+	} else if amount == 0 {
+		return nil, errParameterInvalid("amount")
+	}
+
+	var recipient *user
+	if req.Recipient != nil {
+		if !s.settings.Payment.TransferFunds.IsEnabled || a != nil {
+			return nil, errParameterInvalid("recipientId")
+		}
+		if recipient = s.userByID(*req.Recipient); recipient == nil {
+			return nil, errUserNotFound(strconv.Itoa(*req.Recipient))
+		}
+		if recipient == u {
+			return nil, errParameterInvalid("recipientId")
+		}
+	}
+
+	if limit := s.settings.Payment.Limit; amount < limit.Lower || amount > limit.Upper {
+		return nil, &apiError{schema.ErrorTransactionBoundary, 400, fmt.Sprintf(
+			"Transaction amount '%d' exceeds upper transaction boundary '%d' or lower boundary '%d'",
+			amount, limit.Upper, limit.Lower)}
+	}
+	if err := s.checkBalance(u, amount); err != nil {
+		return nil, err
+	}
+	if recipient != nil {
+		if err := s.checkBalance(recipient, -amount); err != nil {
+			return nil, err
+		}
+	}
+
+	comment := ""
+	if req.Comment != nil {
+		comment = strings.TrimSpace(*req.Comment)
+	}
+
+	tx := s.newTransaction(u, amount, comment)
+	tx.article = a
+	tx.quantity = quantity
+	if recipient != nil {
+		tx.recipient = recipient
+		rtx := s.newTransaction(recipient, -amount, comment)
+		rtx.sender = u
+		tx.linked, rtx.linked = rtx, tx
+	}
+
+	return schema.SingleTransactionResponse{Transaction: s.transactionJSON(tx)}, nil
+}
+
+// Checks whether applying the amount keeps the user's balance within
+// the account boundary. Balances that are already out of bounds may
+// still move back towards them.
+func (s *Server) checkBalance(u *user, amount int) *apiError {
+	limit := s.settings.Account.Limit
+	balance := u.Balance + amount
+	if (amount > 0 && balance > limit.Upper) || (amount < 0 && balance < limit.Lower) {
+		return &apiError{schema.ErrorAccountBalanceBoundary, 400, fmt.Sprintf(
+			"Transaction amount '%d' leads to balance '%d' of user '%s', which exceeds the boundaries '%d' and '%d'",
+			amount, balance, u.Name, limit.Lower, limit.Upper)}
+	}
+	return nil
+}
+
+// Reverts a transaction, and its counterpart if it is a transfer.
+func (s *Server) revertTransaction(tx *transaction) (interface{}, *apiError) {
+	if !s.isDeletable(tx) {
+		return nil, &apiError{schema.ErrorTransactionNotDeletable, 400,
+			fmt.Sprintf("Transaction '%d' is not deletable", tx.id)}
+	}
+
+	for _, t := range []*transaction{tx, tx.linked} {
+		if t == nil {
+			continue
+		}
+		t.deleted = true
+		t.user.Balance -= t.amount
+		s.touch(t.user)
+	}
+
+	return schema.SingleTransactionResponse{Transaction: s.transactionJSON(tx)}, nil
+}
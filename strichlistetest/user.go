@@ -0,0 +1,180 @@
+package strichlistetest
+
+import (
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"net/http"
+	"strings"
+)
+
+func (s *Server) routeUser(r *http.Request, path []string) (interface{}, *apiError) {
+	switch {
+	case len(path) == 0 && r.Method == http.MethodGet:
+		return s.listUsers(r)
+	case len(path) == 0 && r.Method == http.MethodPost:
+		return s.createUser(r)
+	case len(path) == 1 && path[0] == "search" && r.Method == http.MethodGet:
+		return s.searchUsers(r)
+	}
+	if len(path) == 0 {
+		return nil, errNotFound()
+	}
+
+	u, err := s.lookupUser(path[0])
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(path) == 1 && r.Method == http.MethodGet:
+		return schema.SingleUserResponse{User: u.User}, nil
+	case len(path) == 1 && r.Method == http.MethodPost:
+		return s.updateUser(r, u)
+	case len(path) == 2 && path[1] == "metrics" && r.Method == http.MethodGet:
+		return s.userMetrics(u), nil
+	case len(path) >= 2 && path[1] == "transaction":
+		return s.routeUserTransaction(r, u, path[2:])
+	}
+	return nil, errNotFound()
+}
+
+// Finds a user by ID or name.
+func (s *Server) lookupUser(segment string) (*user, *apiError) {
+	var u *user
+	if id, ok := parseID(segment); ok {
+		u = s.userByID(id)
+	} else {
+		u = s.userByName(segment)
+	}
+	if u == nil {
+		return nil, errUserNotFound(segment)
+	}
+	return u, nil
+}
+
+func (s *Server) userByID(id int) *user {
+	if id < 1 || id > len(s.users) {
+		return nil
+	}
+	return s.users[id-1]
+}
+
+func (s *Server) userByName(name string) *user {
+	for _, u := range s.users {
+		if u.Name == name {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) newUser(name string) *user {
+	now := schema.Timestamp(s.now())
+	u := &user{schema.User{
+		ID:          len(s.users) + 1,
+		Name:        name,
+		IsActive:    true,
+		TimeCreated: now,
+		TimeUpdated: now,
+	}}
+	s.users = append(s.users, u)
+	return u
+}
+
+// Marks a user as updated; the server does this on every change,
+// including changes of the balance.
+func (s *Server) touch(u *user) {
+	u.TimeUpdated = schema.Timestamp(s.now())
+}
+
+func (s *Server) listUsers(r *http.Request) (interface{}, *apiError) {
+	from, to := paginate(r, len(s.users))
+	users := make([]schema.User, 0, to-from)
+	for _, u := range s.users[from:to] {
+		users = append(users, u.User)
+	}
+	return schema.MultiUserResponse{Users: users}, nil
+}
+
+func (s *Server) searchUsers(r *http.Request) (interface{}, *apiError) {
+	query := strings.ToLower(r.URL.Query().Get("query"))
+
+	var matches []*user
+	for _, u := range s.users {
+		if strings.Contains(strings.ToLower(u.Name), query) {
+			matches = append(matches, u)
+		}
+	}
+
+	from, to := paginate(r, len(matches))
+	users := make([]schema.User, 0, to-from)
+	for _, u := range matches[from:to] {
+		users = append(users, u.User)
+	}
+	return schema.MultiUserResponse{Users: users}, nil
+}
+
+func (s *Server) createUser(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		Name  string  `json:"name"`
+		Email *string `json:"email"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errParameterMissing("name")
+	}
+	if s.userByName(name) != nil {
+		return nil, errUserAlreadyExists(name)
+	}
+
+	u := s.newUser(name)
+	if req.Email != nil && *req.Email != "" {
+		email := *req.Email
+		u.Email = &email
+	}
+	return schema.SingleUserResponse{User: u.User}, nil
+}
+
+func (s *Server) updateUser(r *http.Request, u *user) (interface{}, *apiError) {
+	var req struct {
+		Name   *string `json:"name"`
+		Email  *string `json:"email"`
+		Active *bool   `json:"active"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errParameterInvalid("name")
+		}
+		if other := s.userByName(name); other != nil && other != u {
+			return nil, errUserAlreadyExists(name)
+		}
+		u.Name = name
+	}
+	if req.Email != nil {
+		email := *req.Email
+		u.Email = &email
+	}
+	if req.Active != nil {
+		u.IsActive = *req.Active
+	}
+
+	s.touch(u)
+	return schema.SingleUserResponse{User: u.User}, nil
+}
+
+func errUserNotFound(user string) *apiError {
+	return &apiError{schema.ErrorUserNotFound, 404, fmt.Sprintf("User '%s' not found", user)}
+}
+
+func errUserAlreadyExists(name string) *apiError {
+	return &apiError{schema.ErrorUserAlreadyExists, 409, fmt.Sprintf("User '%s' already exists", name)}
+}
@@ -0,0 +1,147 @@
+package strichliste
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Reported by SweepPlan.Apply for users that were stale when planning,
+// but whose balance or activity has changed since.
+var ErrNoLongerStale = errors.New("user is no longer stale")
+
+type (
+	// A StaleUser is an active user with a zero balance who hasn't
+	// been active for longer than the stale period.
+	StaleUser struct {
+		User         schema.User
+		LastActivity time.Time // last update or transaction
+	}
+
+	// A SweepPlan lists stale users to deactivate. Print it for a dry
+	// run, then Apply it.
+	SweepPlan struct {
+		client *Client
+
+		Now         time.Time     // reference time for staleness
+		StalePeriod time.Duration // from Settings.User.StalePeriod
+		Users       []StaleUser
+	}
+
+	// The outcome of deactivating a single user.
+	SweepResult struct {
+		User        schema.User  // the user as planned
+		Deactivated *schema.User // the deactivated user, or nil
+		Err         error        // why the user wasn't deactivated
+	}
+)
+
+// Finds stale users, i.e. active users with zero balance whose last
+// activity is longer ago than Settings.User.StalePeriod; see
+// SettingsClient.Cached. Activity is the later of User.TimeUpdated and
+// the user's most recent transaction.
+//
+// Nothing is deactivated until the returned plan is applied.
+func (c *UserClient) PlanStaleSweep(ctx context.Context, now time.Time) (*SweepPlan, error) {
+	settings, err := c.client.Settings.Cached(ctx)
+	if err != nil {
+		return nil, err
+	}
+	period, err := settings.StalePeriod()
+	if err != nil {
+		return nil, err
+	}
+
+	users, _, err := c.ListAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SweepPlan{client: c.client, Now: now, StalePeriod: period}
+	for _, u := range users {
+		if !u.IsActive || u.Balance != 0 {
+			continue
+		}
+		last := settings.Time(u.TimeUpdated)
+		if now.Sub(last) <= period {
+			continue
+		}
+
+		txs, _, err := c.client.Transaction.Context(u.ID).List(ctx, &ListOpts{PerPage: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(txs) > 0 {
+			if t := settings.Time(txs[0].TimeCreated); t.After(last) {
+				last = t
+			}
+		}
+		if now.Sub(last) <= period {
+			continue
+		}
+
+		plan.Users = append(plan.Users, StaleUser{User: u, LastActivity: last})
+	}
+	return plan, nil
+}
+
+// Prints the plan as a table, for previewing it.
+func (p *SweepPlan) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID\tNAME\tLAST ACTIVITY\tIDLE DAYS\n")
+	for _, s := range p.Users {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\n", s.User.ID, s.User.Name,
+			s.LastActivity.Format(schema.TimestampLayout),
+			int(p.Now.Sub(s.LastActivity).Hours()/24))
+	}
+	fmt.Fprintf(tw, "\n%d stale users (stale period: %s)\n", len(p.Users), p.StalePeriod)
+	return tw.Flush()
+}
+
+// Deactivates the planned users, with at most concurrency requests in
+// flight (at least one). Users are fetched again beforehand, and are
+// skipped with ErrNoLongerStale if their balance or activity has
+// changed. Returns one result per planned user, in plan order.
+func (p *SweepPlan) Apply(ctx context.Context, concurrency int) []SweepResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]SweepResult, len(p.Users))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range p.Users {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			results[i] = p.apply(ctx, &p.Users[i])
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (p *SweepPlan) apply(ctx context.Context, s *StaleUser) SweepResult {
+	result := SweepResult{User: s.User}
+
+	u, _, err := p.client.User.Get(ctx, s.User.ID)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if !u.IsActive || u.Balance != 0 || time.Time(u.TimeUpdated) != time.Time(s.User.TimeUpdated) {
+		result.Err = ErrNoLongerStale
+		return result
+	}
+
+	result.Deactivated, _, result.Err = p.client.User.Deactivate(ctx, u.ID)
+	return result
+}
@@ -1,6 +1,7 @@
 package strichliste
 
 import (
+	"context"
 	"fmt"
 	"github.com/jktr/go-strichliste/schema"
 	"net/http"
@@ -19,6 +20,8 @@ type (
 		TransactionClient
 		issuer  int    // user context
 		comment string // stored here to allow easy reuse for many tx's
+
+		idempotencyKey string // empty means no deduplication
 	}
 )
 
@@ -41,40 +44,85 @@ func (c *TransactionContext) WithComment(comment string) *TransactionContext {
 
 // Utility wrapper for Create; see Create for possible errors.
 // Deposit or withdraw funds for the current User; returns the created transaction.
-func (c *TransactionContext) Delta(amount int) (*schema.Transaction, *Response, error) {
+func (c *TransactionContext) Delta(ctx context.Context, amount int) (*schema.Transaction, *Response, error) {
 	tcr := &schema.TransactionCreateRequest{
 		Amount: amount,
 	}
-	return c.Create(tcr)
+	return c.Create(ctx, tcr)
 }
 
 // Utility wrapper for Create; see Create for possible errors.
 // Purchase a number of articles by ID with the current user; returns the created transaction.
-func (c *TransactionContext) Purchase(article int, count int) (*schema.Transaction, *Response, error) {
+// Uses the article cache if enabled, so superseded article IDs purchase the current version; see WithArticleCache.
+func (c *TransactionContext) Purchase(ctx context.Context, article int, count int) (*schema.Transaction, *Response, error) {
+	a, resp, err := c.client.Article.Cached(ctx, article)
+	if err != nil {
+		return nil, resp, err
+	}
+	return c.purchase(ctx, a, count)
+}
 
-	// XXX custom article price is not optional
+// Utility wrapper for Create; see Create and ArticleClient.GetByBarcode for possible errors.
+// Purchase a number of articles by barcode with the current user; returns the created transaction.
+// The barcode must match exactly one active article; check for a *BarcodeError otherwise.
+func (c *TransactionContext) PurchaseByBarcode(ctx context.Context, barcode string, count int) (*schema.Transaction, *Response, error) {
+	a, resp, err := c.client.Article.GetByBarcode(ctx, barcode)
+	if err != nil {
+		return nil, resp, err
+	}
+	return c.purchase(ctx, a, count)
+}
+
+// Utility wrapper for Create; see Create for possible errors.
+// Purchase a number of articles by ID at a custom unit price with the current user; returns the created transaction.
+// The price is positive, like the article's value, and overrides it; the reason is recorded in the comment,
+// see PriceOverrideOf. Reasons must not contain square brackets.
+func (c *TransactionContext) PurchaseAt(ctx context.Context, article int, count int, price int, reason string) (*schema.Transaction, *Response, error) {
+	if err := checkPriceOverride(count, price, reason); err != nil {
+		return nil, nil, err
+	}
 
-	a, resp, err := c.client.Article.Get(article)
+	a, resp, err := c.client.Article.Cached(ctx, article)
 	if err != nil {
 		return nil, resp, err
 	}
 
+	tcr := &schema.TransactionCreateRequest{
+		Amount:    (-price * count),
+		Comment:   withCommentTag(c.comment, priceOverrideTag, reason),
+		ArticleID: &a.ID,
+		Quantity:  &count,
+	}
+	return c.createPurchase(ctx, tcr)
+}
+
+func (c *TransactionContext) createPurchase(ctx context.Context, tcr *schema.TransactionCreateRequest) (*schema.Transaction, *Response, error) {
+	tx, resp, err := c.Create(ctx, tcr)
+	if err != nil {
+		c.client.Article.invalidateOn(err)
+	}
+	return tx, resp, err
+}
+
+func (c *TransactionContext) purchase(ctx context.Context, a *schema.Article, count int) (*schema.Transaction, *Response, error) {
 	tcr := &schema.TransactionCreateRequest{
 		Amount:    (-a.Value * count),
 		ArticleID: &a.ID,
 		Quantity:  &count,
 	}
-	return c.Create(tcr)
+	return c.createPurchase(ctx, tcr)
 }
 
 // Utility wrapper for Create; see Create for possible errors.
 // Transfer an amount of funds from the current user to another by ID; returns the created transaction.
-func (c *TransactionContext) TransferFunds(recipient int, amount int) (*schema.Transaction, *Response, error) {
+// The amount is applied to the current user's balance, so it is negative
+// for sending funds; the recipient's balance changes by its negation.
+func (c *TransactionContext) TransferFunds(ctx context.Context, recipient int, amount int) (*schema.Transaction, *Response, error) {
 	tcr := &schema.TransactionCreateRequest{
 		Amount:    amount,
 		Recipient: &recipient,
 	}
-	return c.Create(tcr)
+	return c.Create(ctx, tcr)
 }
 
 // POST /user/{userId}/transaction
@@ -89,16 +137,28 @@ func (c *TransactionContext) TransferFunds(recipient int, amount int) (*schema.T
 // Consider using these wrappers for specific use-cases:
 //   - Delta
 //   - Purchase
+//   - PurchaseByBarcode
+//   - PurchaseAt
 //   - TransferFunds
-func (c *TransactionContext) Create(trc *schema.TransactionCreateRequest) (*schema.Transaction, *Response, error) {
-	path := fmt.Sprintf("%s/%d%s",
-		schema.EndpointUser, c.issuer, schema.EndpointTransaction)
-
+//
+// If the context carries an idempotency key, an existing transaction
+// with that key is returned instead; see WithIdempotencyKey.
+func (c *TransactionContext) Create(ctx context.Context, trc *schema.TransactionCreateRequest) (*schema.Transaction, *Response, error) {
 	if trc.Comment == "" {
 		trc.Comment = c.comment
 	}
 
-	req, err := c.client.NewRequest(http.MethodPost, path, trc)
+	if c.idempotencyKey != "" {
+		return c.createIdempotent(ctx, trc)
+	}
+	return c.create(ctx, trc)
+}
+
+func (c *TransactionContext) create(ctx context.Context, trc *schema.TransactionCreateRequest) (*schema.Transaction, *Response, error) {
+	path := fmt.Sprintf("%s/%d%s",
+		schema.EndpointUser, c.issuer, schema.EndpointTransaction)
+
+	req, err := c.client.NewRequest(ctx, http.MethodPost, path, trc)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -116,11 +176,11 @@ func (c *TransactionContext) Create(trc *schema.TransactionCreateRequest) (*sche
 //   - ErrorTransactionNotFound
 //
 // Retrieves a transaction by ID.
-func (c *TransactionContext) Get(id int) (*schema.Transaction, *Response, error) {
+func (c *TransactionContext) Get(ctx context.Context, id int) (*schema.Transaction, *Response, error) {
 	path := fmt.Sprintf("%s/%d%s/%d",
 		schema.EndpointUser, c.issuer, schema.EndpointTransaction, id)
 
-	req, err := c.client.NewRequest(http.MethodGet, path, nil)
+	req, err := c.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -137,10 +197,10 @@ func (c *TransactionContext) Get(id int) (*schema.Transaction, *Response, error)
 //
 // Retrieves a list of recent transactions.
 // Pagination is possible via ListOpts, which can be nil.
-func (c *TransactionClient) List(opt *ListOpts) ([]schema.Transaction, *Response, error) {
+func (c *TransactionClient) List(ctx context.Context, opt *ListOpts) ([]schema.Transaction, *Response, error) {
 	path := fmt.Sprintf("%s?%s", schema.EndpointTransaction, opt.values().Encode())
 
-	req, err := c.client.NewRequest(http.MethodGet, path, nil)
+	req, err := c.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -159,11 +219,11 @@ func (c *TransactionClient) List(opt *ListOpts) ([]schema.Transaction, *Response
 //
 // Retrieves a list of transactions issued by this user.
 // Pagination is possible via ListOpts, which can be nil.
-func (c *TransactionContext) List(opt *ListOpts) ([]schema.Transaction, *Response, error) {
+func (c *TransactionContext) List(ctx context.Context, opt *ListOpts) ([]schema.Transaction, *Response, error) {
 	path := fmt.Sprintf("%s/%d%s?%s", schema.EndpointUser, c.issuer,
 		schema.EndpointTransaction, opt.values().Encode())
 
-	req, err := c.client.NewRequest(http.MethodGet, path, nil)
+	req, err := c.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -177,6 +237,40 @@ func (c *TransactionContext) List(opt *ListOpts) ([]schema.Transaction, *Respons
 	return body.Transactions, resp, nil
 }
 
+// GET /transaction
+//
+// Returns an iterator over all transactions, most recent first.
+// Options can be nil.
+func (c *TransactionClient) ListIter(opt *IterOpts) *TransactionIterator {
+	return &TransactionIterator{pager: newPager(opt), list: c.List}
+}
+
+// GET /transaction
+//
+// Retrieves all transactions, walking all pages; see ListIter.
+// Returns the response of the last page.
+func (c *TransactionClient) ListAll(ctx context.Context, opt *IterOpts) ([]schema.Transaction, *Response, error) {
+	return allTransactions(ctx, c.ListIter(opt))
+}
+
+// GET /user/{userId}/transaction
+//   - ErrorUserNotFound
+//
+// Returns an iterator over all transactions issued by this user,
+// most recent first. Options can be nil.
+func (c *TransactionContext) ListIter(opt *IterOpts) *TransactionIterator {
+	return &TransactionIterator{pager: newPager(opt), list: c.List}
+}
+
+// GET /user/{userId}/transaction
+//   - ErrorUserNotFound
+//
+// Retrieves all transactions issued by this user, walking all pages;
+// see ListIter. Returns the response of the last page.
+func (c *TransactionContext) ListAll(ctx context.Context, opt *IterOpts) ([]schema.Transaction, *Response, error) {
+	return allTransactions(ctx, c.ListIter(opt))
+}
+
 // DELETE /user/{userId}/transaction
 //   - ErrorUserNotFound
 //   - ErrorTransactionNotFound
@@ -185,11 +279,11 @@ func (c *TransactionContext) List(opt *ListOpts) ([]schema.Transaction, *Respons
 // Revert a transaction by ID; returns the reversed transaction.
 // Not all transactions are reversible; check Transaction.IsReversible.
 // Note that actual deletion is not possible.
-func (c *TransactionContext) Revert(id int) (*schema.Transaction, *Response, error) {
+func (c *TransactionContext) Revert(ctx context.Context, id int) (*schema.Transaction, *Response, error) {
 	path := fmt.Sprintf("%s/%d%s/%d", schema.EndpointUser,
 		c.issuer, schema.EndpointTransaction, id)
 
-	req, err := c.client.NewRequest(http.MethodDelete, path, nil)
+	req, err := c.client.NewRequest(ctx, http.MethodDelete, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
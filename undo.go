@@ -0,0 +1,181 @@
+package strichliste
+
+import (
+	"context"
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+	"sync"
+	"time"
+)
+
+// The undo window closes this much earlier than the server's deadline,
+// so that a revert started just before the deadline doesn't arrive
+// after it.
+const UndoMargin = 2 * time.Second
+
+// The interval of UndoWindow.Countdown unless configured otherwise.
+const DefaultCountdownInterval = time.Second
+
+// Returned by UndoWindow.Revert once the window has closed. It wraps
+// schema.ErrorTransactionNotDeletable, which the server would have
+// responded with.
+var ErrUndoWindowExpired = fmt.Errorf("undo window expired: %w", schema.ErrorTransactionNotDeletable)
+
+// An UndoWindow tracks the time during which a transaction can still
+// be reverted, e.g. for showing an "Undo (0:42)" button:
+//
+//	w, err := client.Transaction.UndoWindow(ctx, tx)
+//	for remaining := range w.Countdown(time.Second) {
+//		// update button
+//	}
+//	// hide button
+type UndoWindow struct {
+	client   *Client
+	tx       schema.Transaction
+	deadline time.Time
+
+	timer *time.Timer
+	done  chan struct{}
+	once  sync.Once
+}
+
+// Starts tracking the undo window of a transaction, based on the cached
+// settings; see SettingsClient.Cached. The window is already closed if
+// the transaction wasn't reversible when it was retrieved.
+func (c *TransactionClient) UndoWindow(ctx context.Context, tx *schema.Transaction) (*UndoWindow, error) {
+	settings, err := c.client.Settings.Cached(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &UndoWindow{
+		client: c.client,
+		tx:     *tx,
+		done:   make(chan struct{}),
+	}
+
+	if tx.IsReversible && settings.Payment.Reverse.IsEnabled {
+		deadline, err := settings.UndoDeadline(tx)
+		if err != nil {
+			return nil, err
+		}
+		w.deadline = deadline.Add(-UndoMargin)
+	}
+
+	w.start()
+	return w, nil
+}
+
+// Starts the timer that closes the window at its deadline.
+func (w *UndoWindow) start() {
+	remaining := w.Remaining()
+	if remaining <= 0 {
+		w.close()
+		return
+	}
+	w.timer = time.AfterFunc(remaining, w.close)
+}
+
+// Returns the time at which the window closes. Zero if the
+// transaction was never reversible.
+func (w *UndoWindow) Deadline() time.Time {
+	return w.deadline
+}
+
+// Returns the time left until the window closes, or zero if it has.
+func (w *UndoWindow) Remaining() time.Duration {
+	select {
+	case <-w.done:
+		return 0
+	default:
+	}
+	if d := time.Until(w.deadline); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Reports whether the window has closed.
+func (w *UndoWindow) Expired() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns a channel that is closed when the window closes.
+func (w *UndoWindow) Done() <-chan struct{} {
+	return w.done
+}
+
+// Returns a channel that receives the remaining time immediately and
+// then every interval, and that is closed when the window closes.
+// Values are dropped if the receiver falls behind. Intervals of zero
+// or less mean DefaultCountdownInterval.
+func (w *UndoWindow) Countdown(interval time.Duration) <-chan time.Duration {
+	if interval <= 0 {
+		interval = DefaultCountdownInterval
+	}
+	ch := make(chan time.Duration, 1)
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if remaining := w.Remaining(); remaining > 0 {
+				select {
+				case ch <- remaining:
+				default:
+				}
+			}
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Closes the window early, e.g. because the transaction was reverted
+// elsewhere. Releases all resources.
+func (w *UndoWindow) Stop() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.close()
+}
+
+// Closes the window. Unlike Stop, this doesn't touch the timer, so
+// that the timer can call it before it is assigned.
+func (w *UndoWindow) close() {
+	w.once.Do(func() {
+		close(w.done)
+	})
+}
+
+// DELETE /user/{userId}/transaction
+//   - ErrorTransactionNotDeletable
+//
+// Reverts the transaction, unless the window has closed, in which case
+// ErrUndoWindowExpired is returned without contacting the server.
+// Closes the window on success. See TransactionContext.Revert.
+func (w *UndoWindow) Revert(ctx context.Context) (*schema.Transaction, *Response, error) {
+	if w.Expired() {
+		return nil, nil, ErrUndoWindowExpired
+	}
+
+	tx, resp, err := w.client.Transaction.Context(w.tx.Issuer.ID).Revert(ctx, w.tx.ID)
+	if err != nil {
+		return nil, resp, err
+	}
+	w.Stop()
+	return tx, resp, nil
+}
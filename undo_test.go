@@ -0,0 +1,63 @@
+package strichliste
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUndoWindowCountdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{"positive", time.Millisecond},
+		{"zero", 0},
+		{"negative", -time.Second},
+	}
+	for _, tt := range tests {
+		w := &UndoWindow{deadline: time.Now().Add(time.Hour), done: make(chan struct{})}
+		ch := w.Countdown(tt.interval)
+
+		select {
+		case remaining := <-ch:
+			if remaining <= 0 || remaining > time.Hour {
+				t.Errorf("%s: remaining = %v", tt.name, remaining)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: no initial value", tt.name)
+		}
+
+		w.Stop()
+		timeout := time.After(time.Second)
+		for open := true; open; {
+			select {
+			case _, open = <-ch:
+			case <-timeout:
+				t.Fatalf("%s: channel not closed after Stop", tt.name)
+			}
+		}
+	}
+}
+
+func TestUndoWindowExpires(t *testing.T) {
+	// deadlines so close that the timer fires before start returns
+	for i := 0; i < 1000; i++ {
+		w := &UndoWindow{deadline: time.Now().Add(time.Microsecond), done: make(chan struct{})}
+		w.start()
+		select {
+		case <-w.Done():
+		case <-time.After(time.Second):
+			t.Fatal("window not closed at its deadline")
+		}
+		w.Stop()
+		if !w.Expired() || w.Remaining() != 0 {
+			t.Fatal("window open after its deadline")
+		}
+	}
+
+	w := &UndoWindow{deadline: time.Now().Add(-time.Second), done: make(chan struct{})}
+	w.start()
+	if !w.Expired() {
+		t.Error("window open past its deadline")
+	}
+}
@@ -1,6 +1,7 @@
 package strichliste
 
 import (
+	"context"
 	"fmt"
 	"github.com/jktr/go-strichliste/schema"
 	"net/http"
@@ -16,8 +17,8 @@ type UserClient struct {
 //   - ErrorUserAlreadyExists
 //
 // Creates a new user and returns it.
-func (c *UserClient) Create(user *schema.UserCreateRequest) (*schema.User, *Response, error) {
-	req, err := c.client.NewRequest(http.MethodPost, schema.EndpointUser, user)
+func (c *UserClient) Create(ctx context.Context, user *schema.UserCreateRequest) (*schema.User, *Response, error) {
+	req, err := c.client.NewRequest(ctx, http.MethodPost, schema.EndpointUser, user)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -31,10 +32,10 @@ func (c *UserClient) Create(user *schema.UserCreateRequest) (*schema.User, *Resp
 	return &body.User, resp, err
 }
 
-func (c *UserClient) getByX(x string) (*schema.User, *Response, error) {
+func (c *UserClient) getByX(ctx context.Context, x string) (*schema.User, *Response, error) {
 	path := fmt.Sprintf("%s/%s", schema.EndpointUser, x)
 
-	req, err := c.client.NewRequest(http.MethodGet, path, nil)
+	req, err := c.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -51,26 +52,26 @@ func (c *UserClient) getByX(x string) (*schema.User, *Response, error) {
 //   - ErrorUserNotFound
 //
 // Retrieves a user by ID.
-func (c *UserClient) Get(id int) (*schema.User, *Response, error) {
-	return c.getByX(fmt.Sprintf("%d", id))
+func (c *UserClient) Get(ctx context.Context, id int) (*schema.User, *Response, error) {
+	return c.getByX(ctx, fmt.Sprintf("%d", id))
 }
 
 // GET /user/{userID}
 //   - ErrorUserNotFound
 //
 // Retrieves a user by name.
-func (c *UserClient) GetByName(name string) (*schema.User, *Response, error) {
-	return c.getByX(name)
+func (c *UserClient) GetByName(ctx context.Context, name string) (*schema.User, *Response, error) {
+	return c.getByX(ctx, name)
 }
 
 // GET /user
 //
 // Retrieves the list of users (both active and inactive).
 // Pagination is possible via ListOpts, which can be nil.
-func (c *UserClient) List(opt *ListOpts) ([]schema.User, *Response, error) {
+func (c *UserClient) List(ctx context.Context, opt *ListOpts) ([]schema.User, *Response, error) {
 	path := fmt.Sprintf("%s?%s", schema.EndpointUser, opt.values().Encode())
 
-	req, err := c.client.NewRequest(http.MethodGet, path, nil)
+	req, err := c.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -84,18 +85,34 @@ func (c *UserClient) List(opt *ListOpts) ([]schema.User, *Response, error) {
 	return body.Users, resp, nil
 }
 
+// GET /user
+//
+// Returns an iterator over all users (both active and inactive).
+// Options can be nil.
+func (c *UserClient) ListIter(opt *IterOpts) *UserIterator {
+	return &UserIterator{pager: newPager(opt), list: c.List}
+}
+
+// GET /user
+//
+// Retrieves all users, walking all pages; see ListIter.
+// Returns the response of the last page.
+func (c *UserClient) ListAll(ctx context.Context, opt *IterOpts) ([]schema.User, *Response, error) {
+	return allUsers(ctx, c.ListIter(opt))
+}
+
 // GET /user/search
 //
 // Retrieves a list of users whose names match, or include, the passed
 // name. Pagination is possible via ListOpts, which can be nil.
-func (c *UserClient) Search(query string, opt *ListOpts) ([]schema.User, *Response, error) {
+func (c *UserClient) Search(ctx context.Context, query string, opt *ListOpts) ([]schema.User, *Response, error) {
 
 	v := opt.values()
 	v.Add("query", query)
 
 	path := fmt.Sprintf("%s?%s", schema.EndpointUserSearch, v.Encode())
 
-	req, err := c.client.NewRequest(http.MethodGet, path, nil)
+	req, err := c.client.NewRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -109,6 +126,26 @@ func (c *UserClient) Search(query string, opt *ListOpts) ([]schema.User, *Respon
 	return body.Users, resp, nil
 }
 
+// GET /user/search
+//
+// Returns an iterator over all users whose names match, or include,
+// the passed name. Options can be nil.
+func (c *UserClient) SearchIter(query string, opt *IterOpts) *UserIterator {
+	list := func(ctx context.Context, opt *ListOpts) ([]schema.User, *Response, error) {
+		return c.Search(ctx, query, opt)
+	}
+	return &UserIterator{pager: newPager(opt), list: list}
+}
+
+// GET /user/search
+//
+// Retrieves all users whose names match, or include, the passed name,
+// walking all pages; see SearchIter.
+// Returns the response of the last page.
+func (c *UserClient) SearchAll(ctx context.Context, query string, opt *IterOpts) ([]schema.User, *Response, error) {
+	return allUsers(ctx, c.SearchIter(query, opt))
+}
+
 // POST /user/{userId}
 //   - ErrorUserNotFound
 //   - ErrorUserAlreadyExists
@@ -116,10 +153,10 @@ func (c *UserClient) Search(query string, opt *ListOpts) ([]schema.User, *Respon
 //   - ErrorParameterInvalid
 //
 // Updates a user by ID and returns it.
-func (c *UserClient) Update(id int, user *schema.UserUpdateRequest) (*schema.User, *Response, error) {
+func (c *UserClient) Update(ctx context.Context, id int, user *schema.UserUpdateRequest) (*schema.User, *Response, error) {
 	path := fmt.Sprintf("%s/%d", schema.EndpointUser, id)
 
-	req, err := c.client.NewRequest(http.MethodPost, path, user)
+	req, err := c.client.NewRequest(ctx, http.MethodPost, path, user)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -134,8 +171,8 @@ func (c *UserClient) Update(id int, user *schema.UserUpdateRequest) (*schema.Use
 
 // Deactivates a user by ID; returns the deactivated user.
 // Note that actual deletion is not possible.
-func (c *UserClient) Deactivate(id int) (*schema.User, *Response, error) {
-	return c.Update(id, &schema.UserUpdateRequest{
+func (c *UserClient) Deactivate(ctx context.Context, id int) (*schema.User, *Response, error) {
+	return c.Update(ctx, id, &schema.UserUpdateRequest{
 		SetActive: new(bool), // false
 	})
 }
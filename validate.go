@@ -0,0 +1,198 @@
+package strichliste
+
+import (
+	"fmt"
+	"github.com/jktr/go-strichliste/schema"
+)
+
+// A Rule identifies a reason for rejecting a transaction.
+type Rule string
+
+const (
+	// The amount is outside of Settings.Payment.Limit.
+	RuleTransactionBoundary Rule = "TransactionBoundary"
+	// A balance would leave Settings.Account.Limit.
+	RuleAccountBalanceBoundary Rule = "AccountBalanceBoundary"
+	// Deposits are disabled in Settings.Payment.Deposit.
+	RuleDepositDisabled Rule = "DepositDisabled"
+	// Withdrawals are disabled in Settings.Payment.Withdraw.
+	RuleWithdrawDisabled Rule = "WithdrawDisabled"
+	// The amount isn't one of the preset amounts, and custom
+	// amounts are disabled.
+	RuleCustomAmountDisabled Rule = "CustomAmountDisabled"
+	// Transfers are disabled in Settings.Payment.TransferFunds.
+	RuleTransferDisabled Rule = "TransferDisabled"
+	// Sender and recipient of a transfer are the same user.
+	RuleTransferToSelf Rule = "TransferToSelf"
+	// The article has been deactivated.
+	RuleArticleInactive Rule = "ArticleInactive"
+	// The amount is zero, the quantity isn't positive, or a custom
+	// price isn't positive.
+	RuleInvalidAmount Rule = "InvalidAmount"
+)
+
+type (
+	// A Violation describes why a transaction would be rejected.
+	Violation struct {
+		Rule Rule
+
+		// The error class the server would respond with. Empty for
+		// rules that only the official frontend enforces, like
+		// deposit and withdrawal presets.
+		Class schema.ErrorClass
+
+		UserID int          // the affected user
+		Amount int          // the offending amount or resulting balance
+		Limit  schema.Limit // the violated limit, if any
+	}
+
+	// A Validator predicts whether the server would reject a
+	// transaction, based on its settings and the involved users'
+	// current balances. It never contacts the server.
+	Validator struct {
+		settings *schema.Settings
+	}
+)
+
+func (v Violation) Error() string {
+	switch v.Rule {
+	case RuleTransactionBoundary:
+		return fmt.Sprintf("amount %d is outside of the transaction boundary [%d, %d]",
+			v.Amount, v.Limit.Lower, v.Limit.Upper)
+	case RuleAccountBalanceBoundary:
+		return fmt.Sprintf("balance %d of user %d would be outside of the account boundary [%d, %d]",
+			v.Amount, v.UserID, v.Limit.Lower, v.Limit.Upper)
+	case RuleCustomAmountDisabled:
+		return fmt.Sprintf("amount %d is not a preset amount", v.Amount)
+	}
+	return fmt.Sprintf("transaction violates rule %s", v.Rule)
+}
+
+// Create a validator for the given server settings.
+func NewValidator(settings *schema.Settings) *Validator {
+	return &Validator{settings: settings}
+}
+
+// Checks a deposit (positive amount) or withdrawal (negative amount);
+// see TransactionContext.Delta. Returns nil if there are no violations.
+func (v *Validator) Delta(user *schema.User, amount int) []Violation {
+	if amount == 0 {
+		return []Violation{{Rule: RuleInvalidAmount, Class: schema.ErrorParameterInvalid, UserID: user.ID}}
+	}
+
+	var vs []Violation
+
+	preset, disabled := v.settings.Payment.Deposit, RuleDepositDisabled
+	if amount < 0 {
+		preset, disabled = v.settings.Payment.Withdraw, RuleWithdrawDisabled
+	}
+	if !preset.IsEnabled {
+		vs = append(vs, Violation{Rule: disabled, UserID: user.ID, Amount: amount})
+	} else if !preset.AllowCustomAmount && !isPreset(preset, amount) {
+		vs = append(vs, Violation{Rule: RuleCustomAmountDisabled, UserID: user.ID, Amount: amount})
+	}
+
+	vs = append(vs, v.boundaries(user, amount)...)
+	return vs
+}
+
+// Checks the purchase of count articles; see TransactionContext.Purchase.
+// Free articles, i.e. those with a value of zero, are fine.
+// Returns nil if there are no violations.
+func (v *Validator) Purchase(user *schema.User, article *schema.Article, count int) []Violation {
+	return v.purchase(user, article, count, article.Value)
+}
+
+// Checks the purchase of count articles at a custom unit price; see
+// TransactionContext.PurchaseAt. Unlike list prices, custom prices
+// must be positive. Returns nil if there are no violations.
+func (v *Validator) PurchaseAt(user *schema.User, article *schema.Article, count int, price int) []Violation {
+	if price < 1 {
+		return []Violation{{Rule: RuleInvalidAmount, Class: schema.ErrorParameterInvalid, UserID: user.ID}}
+	}
+	return v.purchase(user, article, count, price)
+}
+
+func (v *Validator) purchase(user *schema.User, article *schema.Article, count int, price int) []Violation {
+	if count < 1 {
+		return []Violation{{Rule: RuleInvalidAmount, Class: schema.ErrorParameterInvalid, UserID: user.ID}}
+	}
+
+	var vs []Violation
+	if !article.IsActive {
+		vs = append(vs, Violation{Rule: RuleArticleInactive, Class: schema.ErrorArticleInactive, UserID: user.ID})
+	}
+	vs = append(vs, v.boundaries(user, -price*count)...)
+	return vs
+}
+
+// Checks a transfer of funds; see TransactionContext.TransferFunds.
+// The amount is applied to the sender's balance and its negation to
+// the recipient's, so transfers to the recipient are negative.
+// Returns nil if there are no violations.
+func (v *Validator) TransferFunds(sender, recipient *schema.User, amount int) []Violation {
+	if amount == 0 {
+		return []Violation{{Rule: RuleInvalidAmount, Class: schema.ErrorParameterInvalid, UserID: sender.ID}}
+	}
+
+	var vs []Violation
+	if !v.settings.Payment.TransferFunds.IsEnabled {
+		vs = append(vs, Violation{Rule: RuleTransferDisabled, Class: schema.ErrorParameterInvalid, UserID: sender.ID})
+	}
+	if sender.ID == recipient.ID {
+		vs = append(vs, Violation{Rule: RuleTransferToSelf, Class: schema.ErrorParameterInvalid, UserID: sender.ID})
+	}
+	vs = append(vs, v.boundaries(sender, amount)...)
+	if bv := v.balance(recipient, -amount); bv != nil {
+		vs = append(vs, *bv)
+	}
+	return vs
+}
+
+// Checks the transaction and account boundaries.
+func (v *Validator) boundaries(user *schema.User, amount int) []Violation {
+	var vs []Violation
+	if limit := v.settings.Payment.Limit; amount < limit.Lower || amount > limit.Upper {
+		vs = append(vs, Violation{
+			Rule:   RuleTransactionBoundary,
+			Class:  schema.ErrorTransactionBoundary,
+			UserID: user.ID,
+			Amount: amount,
+			Limit:  limit,
+		})
+	}
+	if bv := v.balance(user, amount); bv != nil {
+		vs = append(vs, *bv)
+	}
+	return vs
+}
+
+// Checks whether applying the amount keeps the user's balance within
+// the account boundary. Like the server, this allows balances that are
+// already out of bounds to move back towards them.
+func (v *Validator) balance(user *schema.User, amount int) *Violation {
+	limit := v.settings.Account.Limit
+	balance := user.Balance + amount
+	if (amount > 0 && balance > limit.Upper) || (amount < 0 && balance < limit.Lower) {
+		return &Violation{
+			Rule:   RuleAccountBalanceBoundary,
+			Class:  schema.ErrorAccountBalanceBoundary,
+			UserID: user.ID,
+			Amount: balance,
+			Limit:  limit,
+		}
+	}
+	return nil
+}
+
+func isPreset(preset schema.AmountPreset, amount int) bool {
+	if amount < 0 {
+		amount = -amount
+	}
+	for _, p := range preset.PresetAmounts {
+		if p == amount {
+			return true
+		}
+	}
+	return false
+}